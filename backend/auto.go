@@ -0,0 +1,48 @@
+package backend
+
+import (
+	"context"
+
+	"github.com/markoczy/crawler/errclass"
+	"github.com/markoczy/crawler/logger"
+)
+
+// AutoBackend loads pages with the http backend and falls back to the browser
+// backend if a page looks like it is rendered by javascript or could not be
+// loaded, urls matching force are always loaded with the browser. Error
+// statuses are returned as they are, the browser would get the same status
+type AutoBackend struct {
+	http    *HTTPBackend
+	browser Backend
+	force   func(url string) bool
+	log     logger.Logger
+}
+
+func NewAuto(http *HTTPBackend, browser Backend, force func(url string) bool, log logger.Logger) *AutoBackend {
+	return &AutoBackend{
+		http:    http,
+		browser: browser,
+		force:   force,
+		log:     log,
+	}
+}
+
+func (b *AutoBackend) GetPage(ctx context.Context, url string) (*Page, error) {
+	if b.force(url) {
+		b.log.Debug("Loading '%s' with browser: Url requires the browser", url)
+		return b.browser.GetPage(ctx, url)
+	}
+	info, err := b.http.getPage(ctx, url)
+	if err != nil {
+		if ctx.Err() != nil || errclass.Classify(err) == errclass.Status {
+			return nil, err
+		}
+		b.log.Debug("Falling back to browser for '%s': %s", url, err.Error())
+		return b.browser.GetPage(ctx, url)
+	}
+	if info.jsRendered() {
+		b.log.Debug("Falling back to browser for '%s': Page looks rendered by javascript", url)
+		return b.browser.GetPage(ctx, url)
+	}
+	return info.page(), nil
+}
@@ -0,0 +1,59 @@
+package backend
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/markoczy/crawler/cli"
+	"github.com/markoczy/crawler/logger"
+	"github.com/markoczy/crawler/session"
+)
+
+type countingBackend struct {
+	calls int
+}
+
+func (b *countingBackend) GetPage(ctx context.Context, url string) (*Page, error) {
+	b.calls++
+	return &Page{URL: url, Links: []string{}}, nil
+}
+
+func TestAutoFallback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/app":
+			w.Write([]byte(`<html><body><div id="root"></div><script src="/app.js"></script></body></html>`))
+		case "/error":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	cfg := cli.ParseArgs(flag.NewFlagSet("auto", flag.ExitOnError), []string{"-url", server.URL})
+	log := logger.New(false, false, false)
+	never := func(string) bool { return false }
+	tests := []struct {
+		url      string
+		fallback bool
+	}{
+		{server.URL + "/app", true},
+		{server.URL + "/missing", false},
+		{server.URL + "/error", false},
+		{"http://127.0.0.1:1/", true},
+	}
+	for _, test := range tests {
+		browser := &countingBackend{}
+		b := NewAuto(NewHTTP(cfg, session.New(), log), browser, never, log)
+		if _, err := b.GetPage(context.Background(), test.url); err != nil && test.fallback {
+			t.Errorf("Unexpected error at '%s': %s", test.url, err.Error())
+		}
+		if fallback := browser.calls > 0; fallback != test.fallback {
+			t.Errorf("Expected browser fallback %v for '%s' but found %v", test.fallback, test.url, fallback)
+		}
+	}
+}
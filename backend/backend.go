@@ -0,0 +1,35 @@
+package backend
+
+import (
+	"context"
+
+	"github.com/markoczy/crawler/extract"
+	"github.com/markoczy/crawler/meta"
+	"github.com/markoczy/crawler/structured"
+	"github.com/markoczy/crawler/types"
+)
+
+// Page is the result of loading a page
+type Page struct {
+	// URL is the url after redirects
+	URL       string
+	Redirects []string
+	Links     []string
+	Network   []types.NetworkRequest
+	// Canonical is the href of <link rel=canonical>, empty if not defined
+	Canonical string
+	// Text is the visible text of the page
+	Text string
+	// Meta is the metadata of the page, nil if not extracted
+	Meta *meta.Metadata
+	// Data is the json-ld, microdata and rdfa structured data of the page
+	Data []structured.Item
+	// Records are the records extracted by the schemas matching the page
+	Records []extract.Record
+}
+
+// Backend loads a page and retrieves its links, loading the page is canceled
+// with the context
+type Backend interface {
+	GetPage(ctx context.Context, url string) (*Page, error)
+}
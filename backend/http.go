@@ -0,0 +1,257 @@
+package backend
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/markoczy/crawler/cli"
+	"github.com/markoczy/crawler/errclass"
+	"github.com/markoczy/crawler/httpfunc"
+	"github.com/markoczy/crawler/logger"
+	"github.com/markoczy/crawler/meta"
+	"github.com/markoczy/crawler/session"
+	"github.com/markoczy/crawler/structured"
+	"github.com/markoczy/crawler/types"
+	"golang.org/x/net/html"
+)
+
+const (
+	// pages with less visible text than this and scripts are considered to be
+	// rendered by javascript
+	minTextLen = 200
+)
+
+// HTTPBackend loads pages with a plain http request and tokenizes the html,
+// no javascript is executed
+type HTTPBackend struct {
+	cfg  cli.CrawlerConfig
+	sess session.Session
+	log  logger.Logger
+}
+
+// pageInfo is the result of the html tokenizer
+type pageInfo struct {
+	url       string
+	redirects []string
+	links     []string
+	canonical string
+	scripts   int
+	textLen   int
+	text      []string
+	meta      *meta.Metadata
+	jsonld    []string
+	appRoot   bool
+}
+
+func NewHTTP(cfg cli.CrawlerConfig, sess session.Session, log logger.Logger) *HTTPBackend {
+	return &HTTPBackend{
+		cfg:  cfg,
+		sess: sess,
+		log:  log,
+	}
+}
+
+func (b *HTTPBackend) GetPage(ctx context.Context, url string) (*Page, error) {
+	info, err := b.getPage(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	return info.page(), nil
+}
+
+func (info *pageInfo) page() *Page {
+	// microdata and rdfa need the dom, only json-ld is found without browser
+	data, _ := structured.Parse(structured.Raw{JSONLD: info.jsonld})
+	return &Page{
+		URL:       info.url,
+		Redirects: info.redirects,
+		Links:     info.links,
+		Network:   []types.NetworkRequest{},
+		Canonical: info.canonical,
+		// one line per text node like the line breaks of innerText
+		Text: strings.Join(info.text, "\n"),
+		Meta: info.meta,
+		Data: data,
+	}
+}
+
+// jsRendered guesses if the page needs a browser to render its content
+func (info *pageInfo) jsRendered() bool {
+	if info.scripts == 0 {
+		return false
+	}
+	return info.appRoot || len(info.links) == 0 || info.textLen < minTextLen
+}
+
+func (b *HTTPBackend) getPage(ctx context.Context, url string) (*pageInfo, error) {
+	var err error
+	var resp *http.Response
+	var redirects []string
+	var info *pageInfo
+	b.log.Debug("Requesting page '%s'", url)
+	if resp, redirects, err = httpfunc.Get(ctx, url, b.cfg, b.sess); err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return nil, errclass.NewStatus(resp.StatusCode)
+	}
+	contentType := resp.Header.Get("content-type")
+	if contentType != "" && !strings.Contains(contentType, "html") {
+		b.log.Debug("Not parsing '%s' with content type '%s'", url, contentType)
+		info = &pageInfo{links: []string{}, meta: meta.New()}
+	} else {
+		// relative links are resolved against the url after redirects
+		if info, err = parseHTML(resp.Body, resp.Request.URL); err != nil {
+			return nil, err
+		}
+	}
+	info.url = resp.Request.URL.String()
+	info.redirects = redirects
+	return info, nil
+}
+
+// parseHTML resolves 'href' and 'src' attributes of all elements like the
+// js.GetLinks script does in the browser and extracts the metadata like the
+// js.GetMetadata script, json-ld blocks are collected like the
+// js.GetStructuredData script
+func parseHTML(r io.Reader, base *url.URL) (*pageInfo, error) {
+	info := &pageInfo{links: []string{}, meta: meta.New()}
+	tokenizer := html.NewTokenizer(r)
+	// text of scripts and styles is not visible
+	hidden := false
+	// text of a json-ld script
+	jsonld := false
+	// text of the title or heading that is currently open
+	capture, captured := "", []string{}
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			if tokenizer.Err() == io.EOF {
+				info.meta.Canonical = info.canonical
+				info.meta.WordCount = meta.WordCount(strings.Join(info.text, " "))
+				return info, nil
+			}
+			return nil, tokenizer.Err()
+		case html.TextToken:
+			if jsonld {
+				info.jsonld = append(info.jsonld, string(tokenizer.Text()))
+			}
+			if !hidden {
+				text := strings.TrimSpace(string(tokenizer.Text()))
+				if capture != "" && text != "" {
+					captured = append(captured, text)
+				}
+				if capture == "title" {
+					continue
+				}
+				info.textLen += len(text)
+				if text != "" {
+					info.text = append(info.text, text)
+				}
+			}
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			if string(name) == "script" || string(name) == "style" {
+				hidden, jsonld = false, false
+			}
+			if string(name) == capture {
+				text := strings.Join(captured, " ")
+				switch capture {
+				case "title":
+					info.meta.Title = text
+				case "h1":
+					info.meta.H1 = append(info.meta.H1, text)
+				case "h2":
+					info.meta.H2 = append(info.meta.H2, text)
+				case "h3":
+					info.meta.H3 = append(info.meta.H3, text)
+				}
+				capture, captured = "", []string{}
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			attrs := map[string]string{}
+			for _, attr := range token.Attr {
+				attrs[attr.Key] = attr.Val
+			}
+			switch token.Data {
+			case "script":
+				info.scripts++
+				hidden = token.Type == html.StartTagToken
+				jsonld = hidden && strings.EqualFold(attrs["type"], "application/ld+json")
+			case "style":
+				hidden = token.Type == html.StartTagToken
+			case "base":
+				if href, found := attrs["href"]; found {
+					if u, err := base.Parse(href); err == nil {
+						base = u
+					}
+				}
+			case "div":
+				id := attrs["id"]
+				info.appRoot = info.appRoot || id == "root" || id == "app" || id == "__next"
+			case "title", "h1", "h2", "h3":
+				if token.Type == html.StartTagToken && capture == "" {
+					capture = token.Data
+				}
+			case "meta":
+				parseMeta(info.meta, attrs)
+			case "link":
+				if strings.EqualFold(attrs["rel"], "canonical") && attrs["href"] != "" {
+					if u, err := base.Parse(strings.TrimSpace(attrs["href"])); err == nil {
+						info.canonical = u.String()
+					}
+				}
+				if strings.EqualFold(attrs["rel"], "alternate") && attrs["hreflang"] != "" {
+					if u, err := base.Parse(strings.TrimSpace(attrs["href"])); err == nil {
+						info.meta.Hreflang[attrs["hreflang"]] = u.String()
+					}
+				}
+			case "a":
+				if hasToken(attrs["rel"], "nofollow") && attrs["href"] != "" {
+					info.meta.Nofollow = appendResolved(info.meta.Nofollow, base, attrs["href"])
+				}
+			}
+			if href, found := attrs["href"]; found && href != "" {
+				info.links = appendResolved(info.links, base, href)
+			} else if src, found := attrs["src"]; found && src != "" {
+				info.links = appendResolved(info.links, base, src)
+			}
+		}
+	}
+}
+
+func parseMeta(m *meta.Metadata, attrs map[string]string) {
+	name := strings.ToLower(attrs["name"])
+	property := strings.ToLower(attrs["property"])
+	switch {
+	case name == "description":
+		m.Description = attrs["content"]
+	case name == "robots":
+		m.Robots = attrs["content"]
+	case strings.HasPrefix(property, "og:"):
+		m.OpenGraph[property[3:]] = attrs["content"]
+	}
+}
+
+// hasToken is true if the space separated list contains the token
+func hasToken(list, token string) bool {
+	for _, t := range strings.Fields(list) {
+		if strings.EqualFold(t, token) {
+			return true
+		}
+	}
+	return false
+}
+
+func appendResolved(links []string, base *url.URL, ref string) []string {
+	u, err := base.Parse(strings.TrimSpace(ref))
+	if err != nil {
+		return links
+	}
+	return append(links, u.String())
+}
@@ -0,0 +1,98 @@
+package backend
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestParseHTML(t *testing.T) {
+	page := `<html><head><base href="/docs/"><link rel="stylesheet" href="style.css"><link rel="canonical" href="/docs/start.html"></head>
+<body>
+	<a href="./1/index.html">Link 1</a>
+	<a href="http://other.com/x">Other</a>
+	<img src="../img/logo.png">
+	<a name="anchor">No link</a>
+</body></html>`
+	base, _ := url.Parse("http://localhost:50000/start/index.html")
+	info, err := parseHTML(strings.NewReader(page), base)
+	if err != nil {
+		t.Fatalf("Failed to parse html: %s", err.Error())
+	}
+	expected := []string{
+		"http://localhost:50000/docs/",
+		"http://localhost:50000/docs/style.css",
+		"http://localhost:50000/docs/start.html",
+		"http://localhost:50000/docs/1/index.html",
+		"http://other.com/x",
+		"http://localhost:50000/img/logo.png",
+	}
+	if strings.Join(info.links, " ") != strings.Join(expected, " ") {
+		t.Errorf("Expected links %v but found %v", expected, info.links)
+	}
+	if info.canonical != "http://localhost:50000/docs/start.html" {
+		t.Errorf("Expected canonical 'http://localhost:50000/docs/start.html' but found '%s'", info.canonical)
+	}
+	if text := info.page().Text; text != "Link 1\nOther\nNo link" {
+		t.Errorf("Expected text 'Link 1\\nOther\\nNo link' but found '%s'", text)
+	}
+	if info.jsRendered() {
+		t.Errorf("Static page detected as javascript rendered")
+	}
+}
+
+func TestJSRendered(t *testing.T) {
+	page := `<html><body><div id="root"></div><script src="/bundle.js"></script></body></html>`
+	base, _ := url.Parse("http://localhost:50000/")
+	info, _ := parseHTML(strings.NewReader(page), base)
+	if !info.jsRendered() {
+		t.Errorf("Single page app not detected as javascript rendered")
+	}
+}
+
+func TestParseMetadata(t *testing.T) {
+	page := `<html><head><title>Docs</title>
+<meta name="description" content="All the docs"><meta name="robots" content="noindex, nofollow">
+<meta property="og:title" content="Docs OG">
+<link rel="alternate" hreflang="de" href="/de/docs"></head>
+<body><h1>Main <em>title</em></h1><h2>Sub</h2><p>Some more words here</p>
+<a href="/ads" rel="sponsored nofollow">Ad</a></body></html>`
+	base, _ := url.Parse("http://localhost:50000/docs")
+	info, err := parseHTML(strings.NewReader(page), base)
+	if err != nil {
+		t.Fatalf("Failed to parse html: %s", err.Error())
+	}
+	m := info.meta
+	if m.Title != "Docs" || m.Description != "All the docs" || m.OpenGraph["title"] != "Docs OG" {
+		t.Errorf("Unexpected title, description or og tags: %+v", m)
+	}
+	if m.Hreflang["de"] != "http://localhost:50000/de/docs" {
+		t.Errorf("Expected hreflang 'de' to be 'http://localhost:50000/de/docs' but found %v", m.Hreflang)
+	}
+	if len(m.H1) != 1 || m.H1[0] != "Main title" || len(m.H2) != 1 || m.H2[0] != "Sub" {
+		t.Errorf("Unexpected headings h1 %v h2 %v", m.H1, m.H2)
+	}
+	if m.WordCount != 8 {
+		t.Errorf("Expected 8 words but found %d", m.WordCount)
+	}
+	if !m.NoFollow() || len(m.Nofollow) != 1 || m.Nofollow[0] != "http://localhost:50000/ads" {
+		t.Errorf("Expected nofollow of robots meta tag and link")
+	}
+}
+
+func TestParseJSONLD(t *testing.T) {
+	page := `<html><head><script type="application/ld+json">{"@type": "Product", "name": "Shoe"}</script>
+<script>var x = 1;</script></head><body><p>Shoe</p></body></html>`
+	base, _ := url.Parse("http://localhost:50000/p/1")
+	info, err := parseHTML(strings.NewReader(page), base)
+	if err != nil {
+		t.Fatalf("Failed to parse html: %s", err.Error())
+	}
+	data := info.page().Data
+	if len(data) != 1 || data[0].Type[0] != "Product" || data[0].Properties["name"] != "Shoe" {
+		t.Errorf("Expected json-ld product 'Shoe' but found %+v", data)
+	}
+	if len(info.text) != 1 {
+		t.Errorf("Expected script text to be hidden but found %v", info.text)
+	}
+}
@@ -0,0 +1,148 @@
+package block
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const (
+	reasonType   = "type"
+	reasonUrl    = "url"
+	reasonDomain = "domain"
+)
+
+// Blocker decides which requests are blocked while rendering a page and
+// counts the blocked requests by reason
+type Blocker interface {
+	Block(resourceType string, u *url.URL) bool
+	Counts() map[string]int
+	// Copy returns a blocker with the same rules and no counts
+	Copy() Blocker
+}
+
+type blocker struct {
+	types   map[string]bool
+	urls    *regexp.Regexp
+	domains map[string]bool
+	counts  map[string]int
+	mux     sync.Mutex
+}
+
+func New(types []string, urls *regexp.Regexp, domains []string) Blocker {
+	typeMap := map[string]bool{}
+	for _, t := range types {
+		typeMap[strings.ToLower(t)] = true
+	}
+	domainMap := map[string]bool{}
+	for _, domain := range domains {
+		domainMap[strings.ToLower(domain)] = true
+	}
+	return &blocker{
+		types:   typeMap,
+		urls:    urls,
+		domains: domainMap,
+		counts:  map[string]int{},
+		mux:     sync.Mutex{},
+	}
+}
+
+func (b *blocker) Block(resourceType string, u *url.URL) bool {
+	reason := b.reason(resourceType, u)
+	if reason == "" {
+		return false
+	}
+	b.mux.Lock()
+	b.counts[reason+":"+strings.ToLower(resourceType)]++
+	b.mux.Unlock()
+	return true
+}
+
+func (b *blocker) Counts() map[string]int {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	ret := map[string]int{}
+	for k, v := range b.counts {
+		ret[k] = v
+	}
+	return ret
+}
+
+func (b *blocker) Copy() Blocker {
+	return &blocker{
+		types:   b.types,
+		urls:    b.urls,
+		domains: b.domains,
+		counts:  map[string]int{},
+		mux:     sync.Mutex{},
+	}
+}
+
+func (b *blocker) reason(resourceType string, u *url.URL) string {
+	if b.types[strings.ToLower(resourceType)] {
+		return reasonType
+	}
+	if b.urls.MatchString(u.String()) {
+		return reasonUrl
+	}
+	if b.blockedDomain(strings.ToLower(u.Hostname())) {
+		return reasonDomain
+	}
+	return ""
+}
+
+// blockedDomain looks up the host and its parent domains (i.e. 'a.ads.com'
+// and 'ads.com'), blocklists have many thousand domains
+func (b *blocker) blockedDomain(host string) bool {
+	for host != "" {
+		if b.domains[host] {
+			return true
+		}
+		i := strings.Index(host, ".")
+		if i < 0 {
+			return false
+		}
+		host = host[i+1:]
+	}
+	return false
+}
+
+// ParseTypes parses a comma separated list of resource types
+func ParseTypes(list string) []string {
+	ret := []string{}
+	for _, t := range strings.Split(list, ",") {
+		t = strings.TrimSpace(t)
+		if t != "" {
+			ret = append(ret, strings.ToLower(t))
+		}
+	}
+	return ret
+}
+
+// ParseDomainFile parses a blocklist file with one domain per line, empty
+// lines and lines starting with '#' are ignored. Lines in hosts file format
+// (i.e. '0.0.0.0 ads.example.com') are supported as well
+func ParseDomainFile(path string) ([]string, error) {
+	var err error
+	var dat []byte
+	if dat, err = ioutil.ReadFile(path); err != nil {
+		return nil, err
+	}
+	ret := []string{}
+	for i, line := range strings.Split(string(dat), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		domain := strings.ToLower(strings.TrimPrefix(fields[len(fields)-1], "."))
+		if strings.ContainsAny(domain, "/:") {
+			return nil, fmt.Errorf("Could not parse domain '%s' at line %d", domain, i+1)
+		}
+		ret = append(ret, domain)
+	}
+	return ret, nil
+}
@@ -0,0 +1,78 @@
+package block
+
+import (
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestBlock(t *testing.T) {
+	b := New([]string{"Image"}, regexp.MustCompile(`/track\?`), []string{"ads.com", "cdn.tracker.net"})
+	tests := []struct {
+		resourceType string
+		url          string
+		blocked      bool
+	}{
+		{"Document", "http://ads.com/", true},
+		{"Script", "http://a.b.ads.com/x.js", true},
+		{"Script", "http://ADS.com:8080/x.js", true},
+		{"Script", "http://notads.com/x.js", false},
+		{"Script", "http://ads.com.evil.org/x.js", false},
+		{"Script", "http://tracker.net/x.js", false},
+		{"Script", "http://eu.cdn.tracker.net/x.js", true},
+		{"image", "http://site.com/logo.png", true},
+		{"XHR", "http://site.com/track?id=1", true},
+		{"XHR", "http://site.com/api", false},
+	}
+	for _, test := range tests {
+		u, _ := url.Parse(test.url)
+		if blocked := b.Block(test.resourceType, u); blocked != test.blocked {
+			t.Errorf("Expected blocked %v for %s '%s' but found %v", test.blocked, test.resourceType, test.url, blocked)
+		}
+	}
+	expected := map[string]int{"domain:document": 1, "domain:script": 3, "type:image": 1, "url:xhr": 1}
+	if !reflect.DeepEqual(b.Counts(), expected) {
+		t.Errorf("Expected counts %v but found %v", expected, b.Counts())
+	}
+	if len(b.Copy().Counts()) != 0 {
+		t.Errorf("Expected copy to start without counts")
+	}
+}
+
+func TestParseDomainFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "block")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %s", err.Error())
+	}
+	defer os.RemoveAll(dir)
+	tests := []struct {
+		content  string
+		expected []string
+	}{
+		{"ads.com\n\n# comment\n.Tracker.net\r\n", []string{"ads.com", "tracker.net"}},
+		{"0.0.0.0 ads.com\n127.0.0.1\tcdn.ads.org\n", []string{"ads.com", "cdn.ads.org"}},
+		{"http://ads.com/\n", nil},
+	}
+	for i, test := range tests {
+		filename := filepath.Join(dir, "list.txt")
+		if err = ioutil.WriteFile(filename, []byte(test.content), 0644); err != nil {
+			t.Fatalf("Failed to write list: %s", err.Error())
+		}
+		domains, err := ParseDomainFile(filename)
+		if test.expected == nil {
+			if err == nil {
+				t.Errorf("Expected error for list %d", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Failed to parse list %d: %s", i, err.Error())
+		} else if !reflect.DeepEqual(domains, test.expected) {
+			t.Errorf("Expected domains %v of list %d but found %v", test.expected, i, domains)
+		}
+	}
+}
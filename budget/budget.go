@@ -0,0 +1,153 @@
+package budget
+
+import (
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	LimitPages     = "max-pages"
+	LimitLinks     = "max-links"
+	LimitBytes     = "max-bytes"
+	LimitHostPages = "max-host-pages"
+	LimitDuration  = "max-duration"
+)
+
+// Limits of a crawl, zero values are unlimited
+type Limits struct {
+	Pages     int
+	Links     int
+	Bytes     int64
+	HostPages int
+	Duration  time.Duration
+}
+
+// Budget counts the pages, links and bytes of a crawl against its limits.
+// The per host limit only skips pages of the host, all other limits stop
+// the crawl once they are hit
+type Budget interface {
+	// Page reserves a visit of the page, false if a limit is hit
+	Page(u string) bool
+	// Link counts a unique link, false if the link is new and a limit is hit
+	Link(u string) bool
+	AddBytes(n int64)
+	// RemainingBytes returns the bytes left until the byte limit is hit, -1
+	// if there is no byte limit
+	RemainingBytes() int64
+	// Done is true if the crawl must stop
+	Done() bool
+	// Hit returns the names of the limits that were hit
+	Hit() []string
+}
+
+type budget struct {
+	limits Limits
+	start  time.Time
+	pages  int
+	hosts  map[string]int
+	links  map[string]bool
+	bytes  int64
+	hit    map[string]bool
+	mux    sync.Mutex
+}
+
+// New starts a budget, the duration is measured from now
+func New(limits Limits) Budget {
+	return &budget{
+		limits: limits,
+		start:  time.Now(),
+		hosts:  map[string]int{},
+		links:  map[string]bool{},
+		hit:    map[string]bool{},
+		mux:    sync.Mutex{},
+	}
+}
+
+func (b *budget) Page(u string) bool {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	if b.done() {
+		return false
+	}
+	if b.limits.Pages > 0 && b.pages >= b.limits.Pages {
+		b.hit[LimitPages] = true
+		return false
+	}
+	host := hostOf(u)
+	if b.limits.HostPages > 0 && b.hosts[host] >= b.limits.HostPages {
+		b.hit[LimitHostPages] = true
+		return false
+	}
+	b.pages++
+	b.hosts[host]++
+	return true
+}
+
+func (b *budget) Link(u string) bool {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	if b.links[u] {
+		return true
+	}
+	if b.limits.Links > 0 && len(b.links) >= b.limits.Links {
+		b.hit[LimitLinks] = true
+		return false
+	}
+	b.links[u] = true
+	return true
+}
+
+func (b *budget) AddBytes(n int64) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	b.bytes += n
+	if b.limits.Bytes > 0 && b.bytes >= b.limits.Bytes {
+		b.hit[LimitBytes] = true
+	}
+}
+
+func (b *budget) RemainingBytes() int64 {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	if b.limits.Bytes <= 0 {
+		return -1
+	}
+	if b.bytes >= b.limits.Bytes {
+		return 0
+	}
+	return b.limits.Bytes - b.bytes
+}
+
+func (b *budget) Done() bool {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	return b.done()
+}
+
+func (b *budget) done() bool {
+	if b.limits.Duration > 0 && time.Since(b.start) >= b.limits.Duration {
+		b.hit[LimitDuration] = true
+	}
+	return b.hit[LimitPages] || b.hit[LimitLinks] || b.hit[LimitBytes] || b.hit[LimitDuration]
+}
+
+func (b *budget) Hit() []string {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	ret := []string{}
+	for k := range b.hit {
+		ret = append(ret, k)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+func hostOf(u string) string {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return ""
+	}
+	return parsed.Host
+}
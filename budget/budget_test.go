@@ -0,0 +1,62 @@
+package budget
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPagesAndHosts(t *testing.T) {
+	b := New(Limits{Pages: 3, HostPages: 2})
+	if !b.Page("http://a/1") || !b.Page("http://a/2") {
+		t.Fatalf("Expected first pages of host to be allowed")
+	}
+	if b.Page("http://a/3") {
+		t.Errorf("Expected third page of host to exceed host limit")
+	}
+	if b.Done() {
+		t.Errorf("Expected host limit not to stop the crawl")
+	}
+	if !b.Page("http://b/1") {
+		t.Errorf("Expected page of other host to be allowed")
+	}
+	if b.Page("http://c/1") || !b.Done() {
+		t.Errorf("Expected page limit to stop the crawl")
+	}
+	expected := []string{LimitHostPages, LimitPages}
+	if !reflect.DeepEqual(b.Hit(), expected) {
+		t.Errorf("Expected hit limits %v but found %v", expected, b.Hit())
+	}
+}
+
+func TestLinks(t *testing.T) {
+	b := New(Limits{Links: 2})
+	for _, u := range []string{"http://a/1", "http://a/2", "http://a/1"} {
+		if !b.Link(u) {
+			t.Errorf("Expected link '%s' to be allowed", u)
+		}
+	}
+	if b.Link("http://a/3") || !b.Done() {
+		t.Errorf("Expected link limit to stop the crawl")
+	}
+}
+
+func TestBytesAndDuration(t *testing.T) {
+	if remaining := New(Limits{}).RemainingBytes(); remaining != -1 {
+		t.Errorf("Expected unlimited bytes but found %d remaining", remaining)
+	}
+	b := New(Limits{Bytes: 100})
+	b.AddBytes(60)
+	if b.Done() || b.RemainingBytes() != 40 {
+		t.Errorf("Expected bytes below limit not to stop the crawl")
+	}
+	b.AddBytes(60)
+	if !b.Done() || b.RemainingBytes() != 0 {
+		t.Errorf("Expected byte limit to stop the crawl")
+	}
+	b = New(Limits{Duration: time.Millisecond})
+	time.Sleep(2 * time.Millisecond)
+	if !b.Done() || b.Hit()[0] != LimitDuration {
+		t.Errorf("Expected duration limit to stop the crawl")
+	}
+}
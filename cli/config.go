@@ -2,8 +2,33 @@ package cli
 
 import (
 	"fmt"
+	"net/http"
+	"net/url"
 	"regexp"
+	"sort"
+	"strings"
 	"time"
+
+	"github.com/markoczy/crawler/budget"
+	"github.com/markoczy/crawler/credential"
+	"github.com/markoczy/crawler/emulation"
+	"github.com/markoczy/crawler/errclass"
+	"github.com/markoczy/crawler/extract"
+	"github.com/markoczy/crawler/scope"
+	"github.com/markoczy/crawler/trap"
+	"github.com/markoczy/crawler/urlnorm"
+)
+
+// LoginField is a form field that is filled with Value during the login phase
+type LoginField struct {
+	Selector string
+	Value    string
+}
+
+const (
+	RendererHTTP    = "http"
+	RendererBrowser = "browser"
+	RendererAuto    = "auto"
 )
 
 type CrawlerConfig interface {
@@ -11,19 +36,69 @@ type CrawlerConfig interface {
 	Test() bool
 	Urls() []string
 	Download() bool
+	CheckLinks() bool
+	Renderer() string
+	Output() string
+	Profiles() []emulation.Profile
 	SkipExisting() bool
 	Depth() int
+	Limits() budget.Limits
 	Timeout() time.Duration
 	ExtraWaittime() time.Duration
 	Headers() map[string]string
+	Credentials() credential.Provider
 	Include() *regexp.Regexp
 	Exclude() *regexp.Regexp
 	FollowInclude() *regexp.Regexp
 	FollowExclude() *regexp.Regexp
+	Scope() scope.Scope
+	Normalizer() urlnorm.Normalizer
+	Canonical() bool
+	Metadata() bool
+	Nofollow() bool
+	Index() string
+	Snapshot() string
+	ChangeReport() string
+	StructuredData() bool
+	Schemas() []*extract.Schema
+	Records() string
+	RecordsFormat() string
+	TrapLimits() trap.Limits
+	TrapReport() string
+	NearDuplicates() bool
+	NearDuplicateDistance() int
+	DuplicateReport() string
 	NamingCapture() *regexp.Regexp
 	NamingCaptureFolders() bool
 	NamingPattern() string
 	ReconnectAttempts() int
+	MaxRedirects() int
+	ErrorPolicy() errclass.Policy
+	SubrequestPolicy() errclass.Policy
+	NetworkLinks() bool
+	SourceInclude() *regexp.Regexp
+	SourceExclude() *regexp.Regexp
+	// Login Config
+	LoginUrl() string
+	LoginFields() []LoginField
+	LoginSubmit() string
+	LoginWait() string
+	LoginSuccess() *regexp.Regexp
+	CookiesImport() string
+	CookiesExport() string
+	Proxies() []*url.URL
+	ProxyRotation() string
+	ProxyMaxFailures() int
+	// Block Config
+	BlockTypes() []string
+	Block() *regexp.Regexp
+	BlockDomains() []string
+	// Browser Config
+	BrowserBin() string
+	BrowserFlags() map[string]string
+	UserDataDir() string
+	Headful() bool
+	RemoteBrowser() string
 	// Log Config
 	LogWarn() bool
 	LogInfo() bool
@@ -36,19 +111,67 @@ type crawlerConfig struct {
 	test                 bool
 	urls                 []string
 	download             bool
+	checkLinks           bool
+	renderer             string
+	output               string
+	profiles             []emulation.Profile
 	skipExisting         bool
 	depth                int
+	limits               budget.Limits
 	timeout              time.Duration
 	extraWaittime        time.Duration
 	headers              map[string]string
+	credentials          credential.Provider
 	include              *regexp.Regexp
 	exclude              *regexp.Regexp
 	followInclude        *regexp.Regexp
 	followExclude        *regexp.Regexp
+	scope                scope.Scope
+	normalize            bool
+	normalizer           urlnorm.Normalizer
+	canonical            bool
+	metadata             bool
+	nofollow             bool
+	index                string
+	snapshot             string
+	changeReport         string
+	structuredData       bool
+	schemas              []*extract.Schema
+	records              string
+	recordsFormat        string
+	trapLimits           trap.Limits
+	trapReport           string
+	nearDuplicates       bool
+	nearDuplicateDist    int
+	duplicateReport      string
 	namingCapture        *regexp.Regexp
 	namingCaptureFolders bool
 	namingPattern        string
 	reconnectAttempts    int
+	maxRedirects         int
+	errorPolicy          errclass.Policy
+	subrequestPolicy     errclass.Policy
+	networkLinks         bool
+	sourceInclude        *regexp.Regexp
+	sourceExclude        *regexp.Regexp
+	loginUrl             string
+	loginFields          []LoginField
+	loginSubmit          string
+	loginWait            string
+	loginSuccess         *regexp.Regexp
+	cookiesImport        string
+	cookiesExport        string
+	proxies              []*url.URL
+	proxyRotation        string
+	proxyMaxFailures     int
+	blockTypes           []string
+	block                *regexp.Regexp
+	blockDomains         []string
+	browserBin           string
+	browserFlags         map[string]string
+	userDataDir          string
+	headful              bool
+	remoteBrowser        string
 	logWarn              bool
 	logInfo              bool
 	logDebug             bool
@@ -66,6 +189,22 @@ func (cfg *crawlerConfig) Download() bool {
 	return cfg.download
 }
 
+func (cfg *crawlerConfig) CheckLinks() bool {
+	return cfg.checkLinks
+}
+
+func (cfg *crawlerConfig) Renderer() string {
+	return cfg.renderer
+}
+
+func (cfg *crawlerConfig) Output() string {
+	return cfg.output
+}
+
+func (cfg *crawlerConfig) Profiles() []emulation.Profile {
+	return cfg.profiles
+}
+
 func (cfg *crawlerConfig) SkipExisting() bool {
 	return cfg.skipExisting
 }
@@ -74,6 +213,10 @@ func (cfg *crawlerConfig) Depth() int {
 	return cfg.depth
 }
 
+func (cfg *crawlerConfig) Limits() budget.Limits {
+	return cfg.limits
+}
+
 func (cfg *crawlerConfig) Timeout() time.Duration {
 	return cfg.timeout
 }
@@ -86,6 +229,10 @@ func (cfg *crawlerConfig) Headers() map[string]string {
 	return cfg.headers
 }
 
+func (cfg *crawlerConfig) Credentials() credential.Provider {
+	return cfg.credentials
+}
+
 func (cfg *crawlerConfig) Include() *regexp.Regexp {
 	return cfg.include
 }
@@ -102,6 +249,74 @@ func (cfg *crawlerConfig) FollowExclude() *regexp.Regexp {
 	return cfg.followExclude
 }
 
+func (cfg *crawlerConfig) Scope() scope.Scope {
+	return cfg.scope
+}
+
+func (cfg *crawlerConfig) Normalizer() urlnorm.Normalizer {
+	return cfg.normalizer
+}
+
+func (cfg *crawlerConfig) Canonical() bool {
+	return cfg.canonical
+}
+
+func (cfg *crawlerConfig) Metadata() bool {
+	return cfg.metadata
+}
+
+func (cfg *crawlerConfig) Nofollow() bool {
+	return cfg.nofollow
+}
+
+func (cfg *crawlerConfig) Index() string {
+	return cfg.index
+}
+
+func (cfg *crawlerConfig) Snapshot() string {
+	return cfg.snapshot
+}
+
+func (cfg *crawlerConfig) ChangeReport() string {
+	return cfg.changeReport
+}
+
+func (cfg *crawlerConfig) StructuredData() bool {
+	return cfg.structuredData
+}
+
+func (cfg *crawlerConfig) Schemas() []*extract.Schema {
+	return cfg.schemas
+}
+
+func (cfg *crawlerConfig) Records() string {
+	return cfg.records
+}
+
+func (cfg *crawlerConfig) RecordsFormat() string {
+	return cfg.recordsFormat
+}
+
+func (cfg *crawlerConfig) TrapLimits() trap.Limits {
+	return cfg.trapLimits
+}
+
+func (cfg *crawlerConfig) TrapReport() string {
+	return cfg.trapReport
+}
+
+func (cfg *crawlerConfig) NearDuplicates() bool {
+	return cfg.nearDuplicates
+}
+
+func (cfg *crawlerConfig) NearDuplicateDistance() int {
+	return cfg.nearDuplicateDist
+}
+
+func (cfg *crawlerConfig) DuplicateReport() string {
+	return cfg.duplicateReport
+}
+
 func (cfg *crawlerConfig) NamingCapture() *regexp.Regexp {
 	return cfg.namingCapture
 }
@@ -118,6 +333,102 @@ func (cfg *crawlerConfig) ReconnectAttempts() int {
 	return cfg.reconnectAttempts
 }
 
+func (cfg *crawlerConfig) MaxRedirects() int {
+	return cfg.maxRedirects
+}
+
+func (cfg *crawlerConfig) ErrorPolicy() errclass.Policy {
+	return cfg.errorPolicy
+}
+
+func (cfg *crawlerConfig) SubrequestPolicy() errclass.Policy {
+	return cfg.subrequestPolicy
+}
+
+func (cfg *crawlerConfig) NetworkLinks() bool {
+	return cfg.networkLinks
+}
+
+func (cfg *crawlerConfig) SourceInclude() *regexp.Regexp {
+	return cfg.sourceInclude
+}
+
+func (cfg *crawlerConfig) SourceExclude() *regexp.Regexp {
+	return cfg.sourceExclude
+}
+
+func (cfg *crawlerConfig) LoginUrl() string {
+	return cfg.loginUrl
+}
+
+func (cfg *crawlerConfig) LoginFields() []LoginField {
+	return cfg.loginFields
+}
+
+func (cfg *crawlerConfig) LoginSubmit() string {
+	return cfg.loginSubmit
+}
+
+func (cfg *crawlerConfig) LoginWait() string {
+	return cfg.loginWait
+}
+
+func (cfg *crawlerConfig) LoginSuccess() *regexp.Regexp {
+	return cfg.loginSuccess
+}
+
+func (cfg *crawlerConfig) CookiesImport() string {
+	return cfg.cookiesImport
+}
+
+func (cfg *crawlerConfig) CookiesExport() string {
+	return cfg.cookiesExport
+}
+
+func (cfg *crawlerConfig) Proxies() []*url.URL {
+	return cfg.proxies
+}
+
+func (cfg *crawlerConfig) ProxyRotation() string {
+	return cfg.proxyRotation
+}
+
+func (cfg *crawlerConfig) ProxyMaxFailures() int {
+	return cfg.proxyMaxFailures
+}
+
+func (cfg *crawlerConfig) BlockTypes() []string {
+	return cfg.blockTypes
+}
+
+func (cfg *crawlerConfig) Block() *regexp.Regexp {
+	return cfg.block
+}
+
+func (cfg *crawlerConfig) BlockDomains() []string {
+	return cfg.blockDomains
+}
+
+func (cfg *crawlerConfig) BrowserBin() string {
+	return cfg.browserBin
+}
+
+func (cfg *crawlerConfig) BrowserFlags() map[string]string {
+	return cfg.browserFlags
+}
+
+func (cfg *crawlerConfig) UserDataDir() string {
+	return cfg.userDataDir
+}
+
+func (cfg *crawlerConfig) Headful() bool {
+	return cfg.headful
+}
+
+func (cfg *crawlerConfig) RemoteBrowser() string {
+	return cfg.remoteBrowser
+}
+
 func (cfg *crawlerConfig) LogWarn() bool {
 	return cfg.logWarn
 }
@@ -131,5 +442,66 @@ func (cfg *crawlerConfig) LogDebug() bool {
 }
 
 func (cfg *crawlerConfig) String() string {
-	return fmt.Sprintf("CrawlerConfig [test: '%v', urls: '%v', download: '%v', depth: '%v', timeout: '%v', headers: '%v', include: '%v', exclude: '%v', follow-include: '%v', follow-exclude: '%v', namingCapture: '%v', namingCaptureFolders: '%v', namingPattern: '%v', reconnectAttempts: '%v', logWarn: '%v', logInfo: '%v', logDebug: '%v']", cfg.test, cfg.urls, cfg.download, cfg.depth, cfg.timeout, cfg.headers, cfg.include.String(), cfg.exclude.String(), cfg.followInclude.String(), cfg.followExclude.String(), cfg.namingCapture.String(), cfg.namingCaptureFolders, cfg.namingPattern, cfg.reconnectAttempts, cfg.logWarn, cfg.logInfo, cfg.logDebug)
+	return fmt.Sprintf("CrawlerConfig [test: '%v', urls: '%v', download: '%v', checkLinks: '%v', renderer: '%v', output: '%v', profiles: '%v', depth: '%v', limits: '%+v', timeout: '%v', headers: '%v', credentials: '%v', include: '%v', exclude: '%v', follow-include: '%v', follow-exclude: '%v', scope: '%v', normalize: '%v', canonical: '%v', metadata: '%v', nofollow: '%v', index: '%v', snapshot: '%v', changeReport: '%v', structuredData: '%v', schemas: '%v', records: '%v', recordsFormat: '%v', trapLimits: '%+v', trapReport: '%v', nearDuplicates: '%v', nearDuplicateDistance: '%v', duplicateReport: '%v', namingCapture: '%v', namingCaptureFolders: '%v', namingPattern: '%v', reconnectAttempts: '%v', maxRedirects: '%v', errorPolicy: '%v', subrequestPolicy: '%v', networkLinks: '%v', source-include: '%v', source-exclude: '%v', loginUrl: '%v', loginFields: '%v', loginSubmit: '%v', loginWait: '%v', loginSuccess: '%v', cookiesImport: '%v', cookiesExport: '%v', proxies: '%v', proxyRotation: '%v', proxyMaxFailures: '%v', blockTypes: '%v', block: '%v', blockDomains: '%v', browserBin: '%v', browserFlags: '%v', userDataDir: '%v', headful: '%v', remoteBrowser: '%v', logWarn: '%v', logInfo: '%v', logDebug: '%v']", cfg.test, cfg.urls, cfg.download, cfg.checkLinks, cfg.renderer, cfg.output, profileNames(cfg.profiles), cfg.depth, cfg.limits, cfg.timeout, cfg.headers, cfg.credentials != nil, cfg.include.String(), cfg.exclude.String(), cfg.followInclude.String(), cfg.followExclude.String(), cfg.scope.Mode(), cfg.normalize, cfg.canonical, cfg.metadata, cfg.nofollow, cfg.index, cfg.snapshot, cfg.changeReport, cfg.structuredData, len(cfg.schemas), cfg.records, cfg.recordsFormat, cfg.trapLimits, cfg.trapReport, cfg.nearDuplicates, cfg.nearDuplicateDist, cfg.duplicateReport, cfg.namingCapture.String(), cfg.namingCaptureFolders, cfg.namingPattern, cfg.reconnectAttempts, cfg.maxRedirects, cfg.errorPolicy, cfg.subrequestPolicy, cfg.networkLinks, cfg.sourceInclude.String(), cfg.sourceExclude.String(), cfg.loginUrl, loginSelectors(cfg.loginFields), cfg.loginSubmit, cfg.loginWait, cfg.loginSuccess.String(), cfg.cookiesImport, cfg.cookiesExport, redactProxies(cfg.proxies), cfg.proxyRotation, cfg.proxyMaxFailures, cfg.blockTypes, cfg.block.String(), len(cfg.blockDomains), cfg.browserBin, cfg.browserFlags, cfg.userDataDir, cfg.headful, cfg.remoteBrowser, cfg.logWarn, cfg.logInfo, cfg.logDebug)
+}
+
+// loginSelectors avoids that the login values (i.e. passwords) are logged
+func loginSelectors(fields []LoginField) []string {
+	ret := []string{}
+	for _, field := range fields {
+		ret = append(ret, field.Selector)
+	}
+	return ret
+}
+
+// LaunchKey identifies the options the browser is launched or connected
+// with, crawls with the same key can share a browser
+func LaunchKey(cfg CrawlerConfig) string {
+	proxies := []string{}
+	for _, u := range cfg.Proxies() {
+		proxies = append(proxies, u.String())
+	}
+	flags := []string{}
+	for name, val := range cfg.BrowserFlags() {
+		flags = append(flags, name+"="+val)
+	}
+	sort.Strings(flags)
+	return fmt.Sprintf("%q %q %q %v %q %q", cfg.RemoteBrowser(), cfg.BrowserBin(), cfg.UserDataDir(), cfg.Headful(), flags, proxies)
+}
+
+// Authorize sets the authorization header of the request if the host is a
+// seed host or in the scope of the crawl, so that tokens are never sent to
+// third party hosts (i.e. cdns or trackers loaded by the pages)
+func Authorize(cfg CrawlerConfig, req *http.Request) error {
+	if cfg.Credentials() == nil || !authorizedHost(cfg, req.URL) {
+		return nil
+	}
+	return credential.Authorize(cfg.Credentials(), req)
+}
+
+func authorizedHost(cfg CrawlerConfig, u *url.URL) bool {
+	for _, seed := range cfg.Urls() {
+		if s, err := url.Parse(seed); err == nil && strings.EqualFold(s.Hostname(), u.Hostname()) {
+			return true
+		}
+	}
+	// scope 'all' allows any host and only the seed hosts are authorized
+	return cfg.Scope().Mode() != scope.ModeAll && cfg.Scope().InScope(u.String())
+}
+
+// redactProxies avoids that proxy passwords are logged
+func redactProxies(proxies []*url.URL) []string {
+	ret := []string{}
+	for _, u := range proxies {
+		ret = append(ret, u.Redacted())
+	}
+	return ret
+}
+
+func profileNames(profiles []emulation.Profile) []string {
+	ret := []string{}
+	for _, profile := range profiles {
+		ret = append(ret, profile.Name)
+	}
+	return ret
 }
@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"flag"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLaunchKey(t *testing.T) {
+	parse := func(name string, args ...string) CrawlerConfig {
+		return ParseArgs(flag.NewFlagSet(name, flag.ExitOnError), append(args, "-url", "http://localhost/"))
+	}
+	a := parse("a", "-proxy", "http://proxy-a:3128")
+	b := parse("b", "-proxy", "http://proxy-b:3128")
+	c := parse("c", "-proxy", "http://proxy-a:3128", "-depth", "3")
+	if LaunchKey(a) == LaunchKey(b) {
+		t.Errorf("Expected jobs with different proxies to need different browsers")
+	}
+	if LaunchKey(a) != LaunchKey(c) {
+		t.Errorf("Expected jobs with the same browser options to share the browser")
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	tests := []struct {
+		scope      string
+		url        string
+		authorized bool
+	}{
+		{"all", "http://localhost:8080/api", true},
+		{"all", "http://cdn.example.com/lib.js", false},
+		{"host", "http://localhost:9090/", true},
+		{"host", "http://example.com/", false},
+		{"hosts", "http://api.example.com/", true},
+		{"hosts", "http://tracker.com/", false},
+	}
+	for _, test := range tests {
+		cfg := ParseArgs(flag.NewFlagSet(test.scope, flag.ExitOnError), []string{
+			"-url", "http://localhost:8080/", "-token-command", "echo token",
+			"-scope", test.scope, "-scope-hosts", "api.example.com",
+		})
+		req, _ := http.NewRequest(http.MethodGet, test.url, nil)
+		if err := Authorize(cfg, req); err != nil {
+			t.Fatalf("Failed to authorize: %s", err.Error())
+		}
+		if authorized := req.Header.Get("authorization") == "Bearer token"; authorized != test.authorized {
+			t.Errorf("Expected authorized %v for '%s' in scope '%s' but found header '%s'", test.authorized, test.url, test.scope, req.Header.Get("authorization"))
+		}
+	}
+}
+
+func TestParseDaemonFlags(t *testing.T) {
+	dir, err := ioutil.TempDir("", "daemon")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %s", err.Error())
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "jobs.yaml")
+	if err = ioutil.WriteFile(filename, []byte(`
+state: `+dir+`
+jobs:
+  - name: valid
+    url: http://localhost/
+    schedule: "@daily"
+  - name: depth
+    url: http://localhost/
+    schedule: "@daily"
+    flags: ["-depth", "x"]
+  - name: policy
+    url: http://localhost/
+    schedule: "@daily"
+    flags: ["-on-error", "dns=explode"]
+`), 0644); err != nil {
+		t.Fatalf("Failed to write jobs file: %s", err.Error())
+	}
+
+	cfg, err := ParseDaemonFlags([]string{"-jobs", filename})
+	if err == nil || !strings.Contains(err.Error(), "job 'depth'") || !strings.Contains(err.Error(), "job 'policy'") {
+		t.Errorf("Expected an error naming the invalid jobs but found %v", err)
+	}
+	if jobs := cfg.Jobs().Jobs; len(jobs) != 1 || jobs[0].Name != "valid" || cfg.Crawl(jobs[0]) == nil {
+		t.Errorf("Expected only the valid job to remain but found %v", jobs)
+	}
+}
+
+func TestParseJobArgs(t *testing.T) {
+	invalid := [][]string{
+		{"-url", "http://localhost/", "-include", "("},
+		{"-url", "@missing-urls.txt"},
+		{"-url", "http://localhost/", "-renderer", "lynx"},
+		{"-url", "http://localhost/", "-logfile", "job.log"},
+		{},
+	}
+	for _, args := range invalid {
+		if _, err := ParseJobArgs(flag.NewFlagSet("job", flag.ContinueOnError), args); err == nil {
+			t.Errorf("Expected an error for args %v", args)
+		}
+	}
+	if _, err := ParseJobArgs(flag.NewFlagSet("job", flag.ContinueOnError), []string{"-url", "http://localhost/"}); err != nil {
+		t.Errorf("Expected valid args but found %s", err.Error())
+	}
+	// the token is obtained when the job runs
+	if _, err := ParseJobArgs(flag.NewFlagSet("job", flag.ContinueOnError), []string{"-url", "http://localhost/", "-token-command", "false"}); err != nil {
+		t.Errorf("Expected a failing token command to be valid but found %s", err.Error())
+	}
+}
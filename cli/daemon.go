@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/markoczy/crawler/jobs"
+)
+
+// DaemonCommand is the name of the subcommand that runs scheduled jobs
+const DaemonCommand = "daemon"
+
+// DaemonConfig is the config of the daemon subcommand, the crawl configs of
+// the jobs are parsed when the daemon starts
+type DaemonConfig interface {
+	Jobs() *jobs.File
+	Crawl(job *jobs.Job) CrawlerConfig
+	LogWarn() bool
+	LogInfo() bool
+	LogDebug() bool
+	String() string
+}
+
+type daemonConfig struct {
+	filename string
+	jobs     *jobs.File
+	crawls   map[*jobs.Job]CrawlerConfig
+	logWarn  bool
+	logInfo  bool
+	logDebug bool
+}
+
+func (cfg *daemonConfig) Jobs() *jobs.File {
+	return cfg.jobs
+}
+
+func (cfg *daemonConfig) Crawl(job *jobs.Job) CrawlerConfig {
+	return cfg.crawls[job]
+}
+
+func (cfg *daemonConfig) LogWarn() bool {
+	return cfg.logWarn
+}
+
+func (cfg *daemonConfig) LogInfo() bool {
+	return cfg.logInfo
+}
+
+func (cfg *daemonConfig) LogDebug() bool {
+	return cfg.logDebug
+}
+
+func (cfg *daemonConfig) String() string {
+	return fmt.Sprintf("DaemonConfig [jobs: '%v', count: '%v', state: '%v', logWarn: '%v', logInfo: '%v', logDebug: '%v']", cfg.filename, len(cfg.jobs.Jobs), cfg.jobs.State, cfg.logWarn, cfg.logInfo, cfg.logDebug)
+}
+
+// ParseDaemonFlags parses the arguments after the daemon subcommand and the
+// flags of all jobs, jobs with invalid flags are removed and returned in the
+// error so that the valid jobs still run
+func ParseDaemonFlags(args []string) (DaemonConfig, error) {
+	var err error
+	cfg := daemonConfig{crawls: map[*jobs.Job]CrawlerConfig{}}
+	fs := flag.NewFlagSet(DaemonCommand, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s %s -jobs FILE [flags]\n", os.Args[0], DaemonCommand)
+		fs.PrintDefaults()
+	}
+	jobsPtr := fs.String("jobs", unset, "path to a yaml or json jobs file with the 'state' directory, shared 'flags' and 'jobs' with 'name', 'url', cron 'schedule' and 'flags', cannot be unset")
+	logFilePtr := fs.String("logfile", unset, "path to log file, defaults to stdout when unset, replaces the log files of the jobs")
+	logWarnPtr := fs.Bool("v", false, "Log warn")
+	logInfoPtr := fs.Bool("vv", false, "Log info (implies '-v')")
+	logDebugPtr := fs.Bool("vvv", false, "Log debug (implies '-vv')")
+	fs.Parse(args)
+	usage = fs.Usage
+
+	if *jobsPtr == unset {
+		exitError("Mandatory value 'jobs' was not defined", errUndefinedFlag)
+	}
+	cfg.filename = *jobsPtr
+	if cfg.jobs, err = jobs.Load(cfg.filename); err != nil {
+		exitError(fmt.Sprintf("Parse of value 'jobs' failed: %s", err.Error()), errParseFailed)
+	}
+	cfg.logWarn = *logWarnPtr
+	cfg.logInfo = *logInfoPtr
+	cfg.logDebug = *logDebugPtr
+	valid := []*jobs.Job{}
+	invalid := []string{}
+	for _, job := range cfg.jobs.Jobs {
+		jobFs := flag.NewFlagSet(job.Name, flag.ContinueOnError)
+		jobFs.SetOutput(ioutil.Discard)
+		crawl, err := ParseJobArgs(jobFs, cfg.jobs.Args(job))
+		if err != nil {
+			invalid = append(invalid, fmt.Sprintf("Invalid flags of job '%s': %s", job.Name, err.Error()))
+			continue
+		}
+		cfg.crawls[job] = crawl
+		valid = append(valid, job)
+	}
+	cfg.jobs.Jobs = valid
+	usage = fs.Usage
+	// the log file of the daemon replaces log files of the jobs
+	log.SetOutput(os.Stdout)
+	if *logFilePtr != unset {
+		file, err := os.Create(*logFilePtr)
+		if err != nil {
+			exitError("Failed to create log file "+*logFilePtr, errGeneral)
+		}
+		log.SetOutput(file)
+	}
+	if len(invalid) > 0 {
+		return &cfg, errors.New(strings.Join(invalid, "; "))
+	}
+	return &cfg, nil
+}
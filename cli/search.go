@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/markoczy/crawler/output"
+)
+
+// SearchCommand is the name of the subcommand that queries an index
+const SearchCommand = "search"
+
+// SearchConfig is the config of the search subcommand
+type SearchConfig interface {
+	Index() string
+	Query() string
+	Limit() int
+	Output() string
+	String() string
+}
+
+type searchConfig struct {
+	index  string
+	query  string
+	limit  int
+	output string
+}
+
+func (cfg *searchConfig) Index() string {
+	return cfg.index
+}
+
+func (cfg *searchConfig) Query() string {
+	return cfg.query
+}
+
+func (cfg *searchConfig) Limit() int {
+	return cfg.limit
+}
+
+func (cfg *searchConfig) Output() string {
+	return cfg.output
+}
+
+func (cfg *searchConfig) String() string {
+	return fmt.Sprintf("SearchConfig [index: '%v', query: '%v', limit: '%v', output: '%v']", cfg.index, cfg.query, cfg.limit, cfg.output)
+}
+
+// ParseSearchFlags parses the arguments after the search subcommand, the
+// query is the remaining arguments
+func ParseSearchFlags(args []string) SearchConfig {
+	cfg := searchConfig{}
+	fs := flag.NewFlagSet(SearchCommand, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s %s -index FILE [flags] QUERY\n", os.Args[0], SearchCommand)
+		fs.PrintDefaults()
+	}
+	indexPtr := fs.String("index", unset, "path to the index written by a crawl with '-index', cannot be unset")
+	limitPtr := fs.Int("limit", 10, "max amount of results, 0 is unlimited")
+	outputPtr := fs.String("output", output.FormatText, "output format, either 'text' (url, score and snippet) or 'jsonl' (one json object per result)")
+	fs.Parse(args)
+
+	fail := func(s string, code int) {
+		fs.Usage()
+		fmt.Println("\nERROR: " + s)
+		os.Exit(code)
+	}
+	if *indexPtr == unset {
+		fail("Mandatory value 'index' was not defined", errUndefinedFlag)
+	}
+	cfg.index = *indexPtr
+	cfg.limit = *limitPtr
+	cfg.output = *outputPtr
+	if cfg.output != output.FormatText && cfg.output != output.FormatJSONL {
+		fail(fmt.Sprintf("Unknown output format '%s', expected '%s' or '%s'", cfg.output, output.FormatText, output.FormatJSONL), errParseFailed)
+	}
+	cfg.query = strings.Join(fs.Args(), " ")
+	if strings.TrimSpace(cfg.query) == "" {
+		fail("Mandatory query was not defined", errUndefinedFlag)
+	}
+	return &cfg
+}
@@ -0,0 +1,29 @@
+package credential
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// NewCommand creates a provider that runs an external command which prints a
+// bearer token to stdout, the token is considered valid for ttl
+func NewCommand(command string, ttl time.Duration) (Provider, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil, fmt.Errorf("Token command must not be empty")
+	}
+	fetch := func() (string, string, time.Time, error) {
+		out, err := exec.Command(args[0], args[1:]...).Output()
+		if err != nil {
+			return "", "", time.Time{}, fmt.Errorf("Token command '%s' failed: %s", command, err.Error())
+		}
+		token := strings.TrimSpace(string(out))
+		if token == "" {
+			return "", "", time.Time{}, fmt.Errorf("Token command '%s' printed no token", command)
+		}
+		return token, "", time.Now().Add(ttl), nil
+	}
+	return newCachedProvider("Bearer", fetch), nil
+}
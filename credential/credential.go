@@ -0,0 +1,79 @@
+package credential
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// tokens are refreshed this long before they expire
+	expirySkew = 30 * time.Second
+)
+
+// Provider provides the value of the authorization header, the token is
+// refreshed when it is about to expire or after it was invalidated (i.e. when
+// the server responded with 401)
+type Provider interface {
+	Header() (string, error)
+	Invalidate()
+}
+
+// Authorize sets the authorization header of the request if a provider is
+// configured
+func Authorize(p Provider, req *http.Request) error {
+	if p == nil {
+		return nil
+	}
+	header, err := p.Header()
+	if err != nil {
+		return err
+	}
+	req.Header.Set("authorization", header)
+	return nil
+}
+
+// fetchFunc retrieves a new token, its type and the time it expires at, an
+// empty type is the default type of the provider
+type fetchFunc func() (token, tokenType string, expires time.Time, err error)
+
+type cachedProvider struct {
+	defaultType string
+	tokenType   string
+	token       string
+	expires     time.Time
+	fetch       fetchFunc
+	mux         sync.Mutex
+}
+
+func (p *cachedProvider) Header() (string, error) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	if p.token == "" || time.Now().Add(expirySkew).After(p.expires) {
+		token, tokenType, expires, err := p.fetch()
+		if err != nil {
+			return "", err
+		}
+		if tokenType == "" {
+			tokenType = p.defaultType
+		}
+		p.token = token
+		p.tokenType = tokenType
+		p.expires = expires
+	}
+	return p.tokenType + " " + p.token, nil
+}
+
+func (p *cachedProvider) Invalidate() {
+	p.mux.Lock()
+	p.token = ""
+	p.mux.Unlock()
+}
+
+func newCachedProvider(defaultType string, fetch fetchFunc) *cachedProvider {
+	return &cachedProvider{
+		defaultType: defaultType,
+		fetch:       fetch,
+		mux:         sync.Mutex{},
+	}
+}
@@ -0,0 +1,126 @@
+package credential
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	GrantClientCredentials = "client-credentials"
+	GrantRefreshToken      = "refresh-token"
+	defaultExpiry          = 1 * time.Hour
+)
+
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+type oauth2Source struct {
+	tokenURL     string
+	grant        string
+	clientID     string
+	clientSecret string
+	refreshToken string
+	scope        string
+	client       *http.Client
+	mux          sync.Mutex
+}
+
+// NewOAuth2 creates a provider that requests tokens from the token endpoint
+// using either the client credentials or the refresh token grant, the header
+// uses the token type of the response and defaults to bearer
+func NewOAuth2(tokenURL, grant, clientID, clientSecret, refreshToken, scope string) (Provider, error) {
+	if grant != GrantClientCredentials && grant != GrantRefreshToken {
+		return nil, fmt.Errorf("Unknown grant '%s', expected '%s' or '%s'", grant, GrantClientCredentials, GrantRefreshToken)
+	}
+	if grant == GrantRefreshToken && refreshToken == "" {
+		return nil, fmt.Errorf("Grant '%s' requires a refresh token", GrantRefreshToken)
+	}
+	src := &oauth2Source{
+		tokenURL:     tokenURL,
+		grant:        grant,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		refreshToken: refreshToken,
+		scope:        scope,
+		client:       &http.Client{Timeout: 30 * time.Second},
+		mux:          sync.Mutex{},
+	}
+	return newCachedProvider("Bearer", src.fetch), nil
+}
+
+func (src *oauth2Source) fetch() (string, string, time.Time, error) {
+	var err error
+	var resp *http.Response
+	src.mux.Lock()
+	defer src.mux.Unlock()
+
+	form := url.Values{}
+	if src.grant == GrantClientCredentials {
+		form.Set("grant_type", "client_credentials")
+	} else {
+		form.Set("grant_type", "refresh_token")
+		form.Set("refresh_token", src.refreshToken)
+	}
+	if src.scope != "" {
+		form.Set("scope", src.scope)
+	}
+	req, err := http.NewRequest("POST", src.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+	req.Header.Set("content-type", "application/x-www-form-urlencoded")
+	req.Header.Set("accept", "application/json")
+	if src.clientID != "" {
+		req.SetBasicAuth(url.QueryEscape(src.clientID), url.QueryEscape(src.clientSecret))
+	}
+	if resp, err = src.client.Do(req); err != nil {
+		return "", "", time.Time{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", "", time.Time{}, fmt.Errorf("Token endpoint '%s' responded with status %d", src.tokenURL, resp.StatusCode)
+	}
+
+	token := tokenResponse{}
+	if err = json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", "", time.Time{}, fmt.Errorf("Could not parse token response: %s", err.Error())
+	}
+	if token.AccessToken == "" {
+		return "", "", time.Time{}, fmt.Errorf("Token endpoint '%s' returned no access token", src.tokenURL)
+	}
+	// refresh tokens may be rotated by the server
+	if token.RefreshToken != "" {
+		src.refreshToken = token.RefreshToken
+	}
+	expires := time.Now().Add(defaultExpiry)
+	if token.ExpiresIn > 0 {
+		expires = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+	return token.AccessToken, tokenType(token.TokenType), expires, nil
+}
+
+// tokenType returns the auth scheme of the token type, types are case
+// insensitive but servers commonly expect the canonical spelling
+func tokenType(s string) string {
+	switch strings.ToLower(s) {
+	case "":
+		return ""
+	case "bearer":
+		return "Bearer"
+	case "mac":
+		return "MAC"
+	case "dpop":
+		return "DPoP"
+	default:
+		return s
+	}
+}
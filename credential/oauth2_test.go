@@ -0,0 +1,79 @@
+package credential
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOAuth2Refresh(t *testing.T) {
+	calls := 0
+	refreshTokens := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("Failed to parse form: %s", err.Error())
+		}
+		refreshTokens = append(refreshTokens, r.PostForm.Get("refresh_token"))
+		w.Header().Set("content-type", "application/json")
+		fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"bearer","expires_in":3600,"refresh_token":"refresh-%d"}`, calls, calls)
+	}))
+	defer server.Close()
+
+	provider, err := NewOAuth2(server.URL, GrantRefreshToken, "client", "secret", "refresh-0", "")
+	if err != nil {
+		t.Fatalf("Failed to create provider: %s", err.Error())
+	}
+	expectHeader(t, provider, "Bearer token-1")
+	expectHeader(t, provider, "Bearer token-1")
+	provider.Invalidate()
+	expectHeader(t, provider, "Bearer token-2")
+
+	if calls != 2 {
+		t.Errorf("Expected 2 calls to token endpoint but found %d", calls)
+	}
+	if refreshTokens[0] != "refresh-0" || refreshTokens[1] != "refresh-1" {
+		t.Errorf("Rotated refresh token was not used: %v", refreshTokens)
+	}
+}
+
+func TestOAuth2ExpiresSoon(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		fmt.Fprintf(w, `{"access_token":"token-%d","expires_in":10}`, calls)
+	}))
+	defer server.Close()
+
+	provider, _ := NewOAuth2(server.URL, GrantClientCredentials, "client", "secret", "", "")
+	// expiry is within the skew, every call fetches a new token
+	expectHeader(t, provider, "Bearer token-1")
+	expectHeader(t, provider, "Bearer token-2")
+}
+
+func TestOAuth2TokenType(t *testing.T) {
+	tokenTypes := []string{"mac", ""}
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"%s","expires_in":3600}`, calls+1, tokenTypes[calls])
+		calls++
+	}))
+	defer server.Close()
+
+	provider, _ := NewOAuth2(server.URL, GrantClientCredentials, "client", "secret", "", "")
+	expectHeader(t, provider, "MAC token-1")
+	provider.Invalidate()
+	// a missing token type defaults to bearer
+	expectHeader(t, provider, "Bearer token-2")
+}
+
+func expectHeader(t *testing.T, provider Provider, expected string) {
+	header, err := provider.Header()
+	if err != nil {
+		t.Fatalf("Failed to get header: %s", err.Error())
+	}
+	if header != expected {
+		t.Errorf("Expected header '%s' but found '%s'", expected, header)
+	}
+}
@@ -0,0 +1,108 @@
+package emulation
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-rod/rod/lib/devices"
+)
+
+const (
+	Phone   = "phone"
+	Tablet  = "tablet"
+	Desktop = "desktop"
+)
+
+var (
+	desktop = devices.Device{
+		Title:          "Desktop",
+		Capabilities:   []string{},
+		UserAgent:      devices.LaptopWithMDPIScreen.UserAgent,
+		AcceptLanguage: "en",
+		Screen: devices.Screen{
+			DevicePixelRatio: 1,
+			Horizontal:       devices.ScreenSize{Width: 1920, Height: 1080},
+			Vertical:         devices.ScreenSize{Width: 1080, Height: 1920},
+		},
+	}.Landescape()
+
+	builtin = map[string]devices.Device{
+		Phone:   devices.IPhoneX,
+		Tablet:  devices.IPad,
+		Desktop: desktop,
+	}
+)
+
+// Profile is a named device that is emulated on each page
+type Profile struct {
+	Name   string
+	Device devices.Device
+}
+
+// Parse parses a profile, spec is either one of the builtin profiles 'phone',
+// 'tablet' and 'desktop' or a custom profile in format
+// 'name:WIDTHxHEIGHT[:SCALE][:touch]'. The user agent of the desktop and custom
+// profiles and the accept language of all profiles are overridden if not empty
+func Parse(spec, userAgent, acceptLanguage string) (Profile, error) {
+	split := strings.Split(strings.TrimSpace(spec), ":")
+	name := split[0]
+	device, found := builtin[name]
+	if found && len(split) > 1 {
+		return Profile{}, fmt.Errorf("Builtin profile '%s' can't be customized", name)
+	}
+	if !found {
+		var err error
+		if device, err = parseCustom(name, split[1:]); err != nil {
+			return Profile{}, err
+		}
+	}
+	if userAgent != "" && name != Phone && name != Tablet {
+		device.UserAgent = userAgent
+	}
+	if acceptLanguage != "" {
+		device.AcceptLanguage = acceptLanguage
+	}
+	return Profile{Name: name, Device: device}, nil
+}
+
+func parseCustom(name string, opts []string) (devices.Device, error) {
+	if name == "" || len(opts) == 0 {
+		return devices.Device{}, fmt.Errorf("Profile '%s' is not builtin and needs a size in format 'name:WIDTHxHEIGHT[:SCALE][:touch]'", name)
+	}
+	size := strings.Split(opts[0], "x")
+	if len(size) != 2 {
+		return devices.Device{}, fmt.Errorf("Could not parse size '%s' of profile '%s'", opts[0], name)
+	}
+	width, err := strconv.Atoi(size[0])
+	if err != nil {
+		return devices.Device{}, fmt.Errorf("Could not parse width of profile '%s': %s", name, err.Error())
+	}
+	height, err := strconv.Atoi(size[1])
+	if err != nil {
+		return devices.Device{}, fmt.Errorf("Could not parse height of profile '%s': %s", name, err.Error())
+	}
+	if width <= 0 || height <= 0 {
+		return devices.Device{}, fmt.Errorf("Size '%s' of profile '%s' must be positive", opts[0], name)
+	}
+	device := devices.Device{
+		Title:          name,
+		Capabilities:   []string{},
+		UserAgent:      desktop.UserAgent,
+		AcceptLanguage: desktop.AcceptLanguage,
+		Screen: devices.Screen{
+			DevicePixelRatio: 1,
+			Vertical:         devices.ScreenSize{Width: width, Height: height},
+		},
+	}
+	for _, opt := range opts[1:] {
+		if opt == "touch" {
+			device.Capabilities = []string{"touch", "mobile"}
+			continue
+		}
+		if device.Screen.DevicePixelRatio, err = strconv.ParseFloat(opt, 64); err != nil || device.Screen.DevicePixelRatio <= 0 {
+			return devices.Device{}, fmt.Errorf("Could not parse option '%s' of profile '%s', expected scale or 'touch'", opt, name)
+		}
+	}
+	return device, nil
+}
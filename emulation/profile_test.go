@@ -0,0 +1,55 @@
+package emulation
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-rod/rod/lib/devices"
+)
+
+func TestParseBuiltin(t *testing.T) {
+	tests := []struct {
+		spec      string
+		userAgent string
+	}{
+		// phones and tablets keep their mobile user agent
+		{Phone, devices.IPhoneX.UserAgent},
+		{Tablet, devices.IPad.UserAgent},
+		{Desktop, "crawler"},
+	}
+	for _, test := range tests {
+		profile, err := Parse(test.spec, "crawler", "de")
+		if err != nil {
+			t.Errorf("Failed to parse '%s': %s", test.spec, err.Error())
+			continue
+		}
+		if profile.Name != test.spec || profile.Device.UserAgent != test.userAgent || profile.Device.AcceptLanguage != "de" {
+			t.Errorf("Unexpected profile '%s': %+v", test.spec, profile)
+		}
+	}
+	if _, err := Parse("phone:100x200", "", ""); err == nil {
+		t.Errorf("Expected error when customizing builtin profile")
+	}
+}
+
+func TestParseCustom(t *testing.T) {
+	profile, err := Parse("kiosk:800x1280:2:touch", "crawler", "")
+	if err != nil {
+		t.Fatalf("Failed to parse custom profile: %s", err.Error())
+	}
+	screen := devices.Screen{DevicePixelRatio: 2, Vertical: devices.ScreenSize{Width: 800, Height: 1280}}
+	if profile.Name != "kiosk" || !reflect.DeepEqual(profile.Device.Screen, screen) {
+		t.Errorf("Unexpected screen of custom profile: %+v", profile.Device.Screen)
+	}
+	if !reflect.DeepEqual(profile.Device.Capabilities, []string{"touch", "mobile"}) {
+		t.Errorf("Expected touch capabilities but found %v", profile.Device.Capabilities)
+	}
+	if profile.Device.UserAgent != "crawler" {
+		t.Errorf("Expected user agent to be overridden but found '%s'", profile.Device.UserAgent)
+	}
+	for _, spec := range []string{"kiosk", ":800x600", "kiosk:800", "kiosk:800xA", "kiosk:0x600", "kiosk:800x600:big", "kiosk:800x600:0"} {
+		if _, err := Parse(spec, "", ""); err == nil {
+			t.Errorf("Expected error for profile '%s'", spec)
+		}
+	}
+}
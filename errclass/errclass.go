@@ -0,0 +1,118 @@
+package errclass
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"syscall"
+)
+
+type Class string
+
+const (
+	DNS         Class = "dns"
+	TLS         Class = "tls"
+	Timeout     Class = "timeout"
+	Refused     Class = "refused"
+	Canceled    Class = "canceled"
+	Status      Class = "status"
+	Unsupported Class = "unsupported"
+	Other       Class = "other"
+)
+
+var (
+	Classes = []Class{DNS, TLS, Timeout, Refused, Canceled, Status, Unsupported, Other}
+
+	// errors that are only recognizable by their message (i.e. errors that
+	// were recovered from a panic or returned by the browser)
+	messages = []struct {
+		class Class
+		msg   string
+	}{
+		{Canceled, "context canceled"},
+		{Timeout, "context deadline exceeded"},
+		{Timeout, "net::err_timed_out"},
+		{DNS, "no such host"},
+		{DNS, "no data of the requested type was found"},
+		{DNS, "net::err_name_not_resolved"},
+		{Refused, "connection refused"},
+		{Refused, "net::err_connection_refused"},
+		{TLS, "x509:"},
+		{TLS, "tls:"},
+		{TLS, "net::err_cert_"},
+		{Unsupported, "unsupported protocol scheme"},
+	}
+)
+
+// Error is an error with its class, status is set for errors of class Status
+type Error struct {
+	Class  Class
+	Status int
+	Err    error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s error: %s", e.Class, e.Err.Error())
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// New wraps the error with its class, nil stays nil
+func New(err error) error {
+	if err == nil {
+		return nil
+	}
+	var classified *Error
+	if errors.As(err, &classified) {
+		return err
+	}
+	return &Error{Class: Classify(err), Err: err}
+}
+
+// NewStatus creates an error for a http status code
+func NewStatus(status int) error {
+	return &Error{Class: Status, Status: status, Err: fmt.Errorf("server responded with status %d", status)}
+}
+
+// Classify returns the class of an error, errors that are already classified
+// keep their class
+func Classify(err error) Class {
+	var classified *Error
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	var certErr x509.CertificateInvalidError
+	var unknownAuthErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var recordErr tls.RecordHeaderError
+	switch {
+	case err == nil:
+		return ""
+	case errors.As(err, &classified):
+		return classified.Class
+	case errors.Is(err, context.Canceled):
+		return Canceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return Timeout
+	case errors.As(err, &dnsErr):
+		return DNS
+	case errors.As(err, &certErr), errors.As(err, &unknownAuthErr), errors.As(err, &hostnameErr), errors.As(err, &recordErr):
+		return TLS
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return Refused
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return Timeout
+	}
+	msg := strings.ToLower(err.Error())
+	for _, m := range messages {
+		if strings.Contains(msg, m.msg) {
+			return m.class
+		}
+	}
+	return Other
+}
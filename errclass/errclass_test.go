@@ -0,0 +1,64 @@
+package errclass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected Class
+	}{
+		{context.Canceled, Canceled},
+		{fmt.Errorf("wrapped: %w", context.DeadlineExceeded), Timeout},
+		{&net.DNSError{Err: "no such host", Name: "example.invalid"}, DNS},
+		{errors.New("navigation failed: net::ERR_NAME_NOT_RESOLVED"), DNS},
+		{errors.New("Get \"\": unsupported protocol scheme \"\""), Unsupported},
+		{errors.New("dial tcp 127.0.0.1:1: connect: connection refused"), Refused},
+		{NewStatus(503), Status},
+		{New(context.Canceled), Canceled},
+		{errors.New("something else"), Other},
+	}
+	for _, test := range tests {
+		if found := Classify(test.err); found != test.expected {
+			t.Errorf("Expected class '%s' for error '%s' but found '%s'", test.expected, test.err.Error(), found)
+		}
+	}
+}
+
+func TestPolicyParse(t *testing.T) {
+	policy := DefaultPolicy(5)
+	if err := policy.Parse("timeout=retry:3, tls=ignore,canceled=abort"); err != nil {
+		t.Fatalf("Failed to parse policy: %s", err.Error())
+	}
+	if action := policy.For(context.DeadlineExceeded); action.Kind != Retry || action.Retries != 3 {
+		t.Errorf("Unexpected action for timeout: %s", action.String())
+	}
+	if action := policy.For(context.Canceled); action.Kind != Abort {
+		t.Errorf("Unexpected action for canceled: %s", action.String())
+	}
+	for _, invalid := range []string{"unknown=ignore", "dns=skip", "dns", "dns=retry:x"} {
+		if err := DefaultPolicy(5).Parse(invalid); err == nil {
+			t.Errorf("Expected error for policy '%s'", invalid)
+		}
+	}
+}
+
+func TestDefaultSubrequestPolicy(t *testing.T) {
+	policy := DefaultSubrequestPolicy()
+	for _, err := range []error{errors.New("unknown"), context.DeadlineExceeded, context.Canceled, NewStatus(500)} {
+		if action := policy.For(err); action.Kind != Ignore {
+			t.Errorf("Expected sub-request error '%s' to be ignored but found %s", err.Error(), action.String())
+		}
+	}
+	if err := policy.Parse("timeout=retry:2"); err != nil {
+		t.Fatalf("Failed to parse policy: %s", err.Error())
+	}
+	if action := policy.For(context.DeadlineExceeded); action.Kind != Retry || action.Retries != 2 {
+		t.Errorf("Unexpected action for timeout: %s", action.String())
+	}
+}
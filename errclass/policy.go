@@ -0,0 +1,108 @@
+package errclass
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	Ignore = "ignore"
+	Retry  = "retry"
+	Abort  = "abort"
+)
+
+// Action is what to do when an error of a class occurs, Retries is only used
+// by action Retry
+type Action struct {
+	Kind    string
+	Retries int
+}
+
+func (a Action) String() string {
+	if a.Kind == Retry {
+		return fmt.Sprintf("%s:%d", a.Kind, a.Retries)
+	}
+	return a.Kind
+}
+
+// Policy maps error classes to actions
+type Policy map[Class]Action
+
+// DefaultPolicy returns the default actions, canceled contexts are retried
+// as often as configured by the reconnect attempts
+func DefaultPolicy(reconnectAttempts int) Policy {
+	return Policy{
+		DNS:         {Kind: Ignore},
+		TLS:         {Kind: Abort},
+		Timeout:     {Kind: Retry, Retries: 1},
+		Refused:     {Kind: Retry, Retries: 3},
+		Canceled:    {Kind: Retry, Retries: reconnectAttempts},
+		Status:      {Kind: Ignore},
+		Unsupported: {Kind: Ignore},
+		Other:       {Kind: Retry, Retries: 3},
+	}
+}
+
+// DefaultSubrequestPolicy returns the default actions for the sub-requests of
+// a page (i.e. images, scripts, xhr), failing sub-resources are ignored and
+// never retried so that they don't slow down the page
+func DefaultSubrequestPolicy() Policy {
+	ret := Policy{}
+	for _, class := range Classes {
+		ret[class] = Action{Kind: Ignore}
+	}
+	return ret
+}
+
+// For returns the action for the class of the error
+func (p Policy) For(err error) Action {
+	if action, found := p[Classify(err)]; found {
+		return action
+	}
+	return Action{Kind: Abort}
+}
+
+// Parse overrides the policy by a comma separated list in format
+// 'class=action' where action is 'ignore', 'abort' or 'retry:N'
+func (p Policy) Parse(list string) error {
+	for _, s := range strings.Split(list, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		split := strings.SplitN(s, "=", 2)
+		if len(split) != 2 {
+			return fmt.Errorf("Could not parse '%s' missing separator '='", s)
+		}
+		class := Class(strings.ToLower(strings.TrimSpace(split[0])))
+		if _, found := p[class]; !found {
+			return fmt.Errorf("Unknown error class '%s', expected one of %v", class, Classes)
+		}
+		action, err := parseAction(strings.TrimSpace(split[1]))
+		if err != nil {
+			return err
+		}
+		p[class] = action
+	}
+	return nil
+}
+
+func parseAction(s string) (Action, error) {
+	split := strings.SplitN(strings.ToLower(s), ":", 2)
+	switch split[0] {
+	case Ignore, Abort:
+		return Action{Kind: split[0]}, nil
+	case Retry:
+		retries := 1
+		if len(split) == 2 {
+			var err error
+			if retries, err = strconv.Atoi(split[1]); err != nil || retries < 0 {
+				return Action{}, fmt.Errorf("Could not parse retries of action '%s'", s)
+			}
+		}
+		return Action{Kind: Retry, Retries: retries}, nil
+	default:
+		return Action{}, fmt.Errorf("Unknown action '%s', expected '%s', '%s' or '%s:N'", s, Ignore, Abort, Retry)
+	}
+}
@@ -0,0 +1,47 @@
+package errclass
+
+import (
+	"errors"
+)
+
+const (
+	StepConnect  = "connect"
+	StepOpen     = "open"
+	StepEmulate  = "emulate"
+	StepNavigate = "navigate"
+	StepLoad     = "load"
+	StepWait     = "wait"
+	StepEval     = "eval"
+)
+
+// StepError is an error of a single step of loading a page in the browser
+type StepError struct {
+	Step string
+	Err  error
+}
+
+func (e *StepError) Error() string {
+	return e.Step + ": " + e.Err.Error()
+}
+
+func (e *StepError) Unwrap() error {
+	return e.Err
+}
+
+// NewStep wraps the error with the step it occurred in, nil stays nil
+func NewStep(step string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &StepError{Step: step, Err: err}
+}
+
+// StepOf returns the step an error occurred in or an empty string if the
+// error did not occur in a browser step
+func StepOf(err error) string {
+	var stepErr *StepError
+	if errors.As(err, &stepErr) {
+		return stepErr.Step
+	}
+	return ""
+}
@@ -0,0 +1,138 @@
+package extract
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Field is a value extracted from the elements matching the css selector,
+// the value is the text of an element or the value of the attribute
+type Field struct {
+	Name      string `yaml:"name" json:"name"`
+	Selector  string `yaml:"selector" json:"selector"`
+	Attribute string `yaml:"attribute,omitempty" json:"attribute,omitempty"`
+	// Regex is applied to each value, the first capture group or the whole
+	// match is kept, values not matching are dropped
+	Regex string `yaml:"regex,omitempty" json:"-"`
+	// List keeps all values, otherwise the first value is kept
+	List  bool `yaml:"list,omitempty" json:"-"`
+	regex *regexp.Regexp
+}
+
+// Schema defines the fields extracted from pages with urls matching URL
+type Schema struct {
+	Name   string  `yaml:"name"`
+	URL    string  `yaml:"url"`
+	Fields []Field `yaml:"fields"`
+	url    *regexp.Regexp
+}
+
+// Record is the data extracted from a page by a schema
+type Record struct {
+	Schema string
+	URL    string
+	Fields map[string]interface{}
+}
+
+// Load reads the schemas from a yaml or json file (json is valid yaml)
+func Load(filename string) ([]*Schema, error) {
+	dat, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(dat)
+}
+
+// Parse parses a list of schemas
+func Parse(dat []byte) ([]*Schema, error) {
+	var err error
+	schemas := []*Schema{}
+	if err = yaml.Unmarshal(dat, &schemas); err != nil {
+		return nil, err
+	}
+	for i, s := range schemas {
+		if s.Name == "" {
+			s.Name = fmt.Sprintf("schema-%d", i+1)
+		}
+		if s.url, err = regexp.Compile(s.URL); err != nil {
+			return nil, fmt.Errorf("Invalid url regex of schema '%s': %s", s.Name, err.Error())
+		}
+		for j := range s.Fields {
+			f := &s.Fields[j]
+			if f.Name == "" || f.Selector == "" {
+				return nil, fmt.Errorf("Field %d of schema '%s' requires a name and a selector", j+1, s.Name)
+			}
+			if f.Regex == "" {
+				continue
+			}
+			if f.regex, err = regexp.Compile(f.Regex); err != nil {
+				return nil, fmt.Errorf("Invalid regex of field '%s' in schema '%s': %s", f.Name, s.Name, err.Error())
+			}
+		}
+	}
+	return schemas, nil
+}
+
+// Match returns the schemas matching the url
+func Match(schemas []*Schema, url string) []*Schema {
+	ret := []*Schema{}
+	for _, s := range schemas {
+		if s.url.MatchString(url) {
+			ret = append(ret, s)
+		}
+	}
+	return ret
+}
+
+// FieldNames returns the names of all fields of the schemas in order
+func FieldNames(schemas []*Schema) []string {
+	ret := []string{}
+	found := map[string]bool{}
+	for _, s := range schemas {
+		for _, f := range s.Fields {
+			if !found[f.Name] {
+				found[f.Name] = true
+				ret = append(ret, f.Name)
+			}
+		}
+	}
+	return ret
+}
+
+// Record post-processes the raw values of the fields found on the page
+func (s *Schema) Record(url string, values map[string][]string) Record {
+	ret := Record{Schema: s.Name, URL: url, Fields: map[string]interface{}{}}
+	for _, f := range s.Fields {
+		processed := []string{}
+		for _, v := range values[f.Name] {
+			if v, ok := f.process(v); ok {
+				processed = append(processed, v)
+			}
+		}
+		if f.List {
+			ret.Fields[f.Name] = processed
+		} else if len(processed) > 0 {
+			ret.Fields[f.Name] = processed[0]
+		} else {
+			ret.Fields[f.Name] = nil
+		}
+	}
+	return ret
+}
+
+func (f *Field) process(v string) (string, bool) {
+	if f.regex == nil {
+		return v, true
+	}
+	match := f.regex.FindStringSubmatch(v)
+	if match == nil {
+		return "", false
+	}
+	if len(match) > 1 {
+		return match[1], true
+	}
+	return match[0], true
+}
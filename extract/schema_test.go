@@ -0,0 +1,80 @@
+package extract
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+const schemaYAML = `
+- name: product
+  url: "/p/"
+  fields:
+    - name: title
+      selector: h1
+    - name: price
+      selector: .price
+      regex: "([0-9]+\\.[0-9]+)"
+    - name: images
+      selector: img.gallery
+      attribute: src
+      list: true
+- name: article
+  url: "/blog/"
+  fields:
+    - name: title
+      selector: h1
+`
+
+func TestParseAndRecord(t *testing.T) {
+	schemas, err := Parse([]byte(schemaYAML))
+	if err != nil {
+		t.Fatalf("Failed to parse schemas: %s", err.Error())
+	}
+	matched := Match(schemas, "http://shop/p/1")
+	if len(matched) != 1 || matched[0].Name != "product" {
+		t.Fatalf("Expected schema 'product' to match")
+	}
+	rec := matched[0].Record("http://shop/p/1", map[string][]string{
+		"title":  {"Shoe", "Other"},
+		"price":  {"CHF 12.50"},
+		"images": {"http://shop/1.png", "http://shop/2.png"},
+	})
+	expected := map[string]interface{}{
+		"title":  "Shoe",
+		"price":  "12.50",
+		"images": []string{"http://shop/1.png", "http://shop/2.png"},
+	}
+	if !reflect.DeepEqual(rec.Fields, expected) {
+		t.Errorf("Expected fields %v but found %v", expected, rec.Fields)
+	}
+	if !reflect.DeepEqual(FieldNames(schemas), []string{"title", "price", "images"}) {
+		t.Errorf("Unexpected field names %v", FieldNames(schemas))
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	schemas, err := Parse([]byte(`[{"name": "a", "url": ".*", "fields": [{"name": "h", "selector": "h1", "list": true}]}]`))
+	if err != nil || len(schemas) != 1 || !schemas[0].Fields[0].List {
+		t.Errorf("Failed to parse json schema: %v", err)
+	}
+	if _, err = Parse([]byte(`[{"url": ".*", "fields": [{"name": "h"}]}]`)); err == nil {
+		t.Errorf("Expected error for field without selector")
+	}
+}
+
+func TestCSVWriter(t *testing.T) {
+	schemas, _ := Parse([]byte(schemaYAML))
+	buf := &bytes.Buffer{}
+	w, err := NewWriter(FormatCSV, buf, schemas)
+	if err != nil {
+		t.Fatalf("Failed to create writer: %s", err.Error())
+	}
+	w.Write(schemas[0].Record("http://shop/p/1", map[string][]string{"title": {"Shoe"}, "images": {"1.png", "2.png"}}))
+	// the page is extracted again by the crawl of another profile
+	w.Write(schemas[0].Record("http://shop/p/1", map[string][]string{"title": {"Shoe (mobile)"}}))
+	expected := "schema,url,title,price,images\nproduct,http://shop/p/1,Shoe,,1.png|2.png\n"
+	if buf.String() != expected {
+		t.Errorf("Expected csv %q but found %q", expected, buf.String())
+	}
+}
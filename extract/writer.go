@@ -0,0 +1,111 @@
+package extract
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+const (
+	FormatJSONL = "jsonl"
+	FormatCSV   = "csv"
+	// listSep joins list values in csv cells
+	listSep = "|"
+)
+
+// Writer writes records in the selected format, records are keyed by schema
+// and url and a page that is extracted again (i.e. once per emulated profile)
+// is only written the first time
+type Writer interface {
+	Write(rec Record) error
+	Flush() error
+}
+
+// NewWriter creates a writer, the csv columns are 'schema', 'url' and the
+// fields of all schemas
+func NewWriter(format string, w io.Writer, schemas []*Schema) (Writer, error) {
+	switch format {
+	case FormatJSONL:
+		return newUniqueWriter(&jsonlWriter{enc: json.NewEncoder(w)}), nil
+	case FormatCSV:
+		cw := &csvWriter{w: csv.NewWriter(w), columns: FieldNames(schemas)}
+		if err := cw.w.Write(append([]string{"schema", "url"}, cw.columns...)); err != nil {
+			return nil, err
+		}
+		return newUniqueWriter(cw), nil
+	default:
+		return nil, fmt.Errorf("Unknown records format '%s', expected '%s' or '%s'", format, FormatJSONL, FormatCSV)
+	}
+}
+
+type uniqueWriter struct {
+	Writer
+	written map[string]bool
+	mux     sync.Mutex
+}
+
+func newUniqueWriter(w Writer) Writer {
+	return &uniqueWriter{Writer: w, written: map[string]bool{}, mux: sync.Mutex{}}
+}
+
+func (uw *uniqueWriter) Write(rec Record) error {
+	key := rec.Schema + " " + rec.URL
+	uw.mux.Lock()
+	defer uw.mux.Unlock()
+	if uw.written[key] {
+		return nil
+	}
+	uw.written[key] = true
+	return uw.Writer.Write(rec)
+}
+
+type jsonlWriter struct {
+	enc *json.Encoder
+}
+
+type jsonlRecord struct {
+	Schema string                 `json:"schema"`
+	URL    string                 `json:"url"`
+	Fields map[string]interface{} `json:"fields"`
+}
+
+func (jw *jsonlWriter) Write(rec Record) error {
+	return jw.enc.Encode(jsonlRecord(rec))
+}
+
+func (jw *jsonlWriter) Flush() error {
+	return nil
+}
+
+type csvWriter struct {
+	w       *csv.Writer
+	columns []string
+}
+
+func (cw *csvWriter) Write(rec Record) error {
+	row := []string{rec.Schema, rec.URL}
+	for _, column := range cw.columns {
+		switch v := rec.Fields[column].(type) {
+		case string:
+			row = append(row, v)
+		case []string:
+			row = append(row, strings.Join(v, listSep))
+		default:
+			row = append(row, "")
+		}
+	}
+	if err := cw.w.Write(row); err != nil {
+		return err
+	}
+	// records are flushed one by one so they survive an interrupted crawl
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+func (cw *csvWriter) Flush() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
@@ -1,8 +1,15 @@
 module github.com/markoczy/crawler
 
-go 1.15
+go 1.17
 
 require (
 	github.com/go-rod/rod v0.101.8
-	github.com/ysmood/gson v0.7.0
+	golang.org/x/net v0.11.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/ysmood/goob v0.3.0 // indirect
+	github.com/ysmood/gson v0.7.0 // indirect
+	github.com/ysmood/leakless v0.7.0 // indirect
 )
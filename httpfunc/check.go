@@ -0,0 +1,47 @@
+package httpfunc
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/markoczy/crawler/cli"
+	"github.com/markoczy/crawler/session"
+)
+
+// CheckResult is the status of a link, Redirects holds the urls the link
+// redirected to in order
+type CheckResult struct {
+	URL       string
+	Status    int
+	Redirects []string
+	Err       error
+}
+
+// Broken is true if the link could not be loaded or responded with an error
+// status
+func (res *CheckResult) Broken() bool {
+	return res.Err != nil || res.Status >= 400
+}
+
+// Check requests the url with HEAD and records the status and redirects,
+// servers that don't support HEAD are requested with GET
+func Check(ctx context.Context, url string, cfg cli.CrawlerConfig, sess session.Session) *CheckResult {
+	res := check(ctx, http.MethodHead, url, cfg, sess)
+	if res.Status == http.StatusMethodNotAllowed || res.Status == http.StatusNotImplemented {
+		res = check(ctx, http.MethodGet, url, cfg, sess)
+	}
+	return res
+}
+
+func check(ctx context.Context, method, url string, cfg cli.CrawlerConfig, sess session.Session) *CheckResult {
+	redirects := NewRedirects(cfg.MaxRedirects())
+	resp, err := do(ctx, method, url, cfg, redirects.Client(sess.Client()))
+	res := &CheckResult{URL: url, Redirects: redirects.URLs}
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	resp.Body.Close()
+	res.Status = resp.StatusCode
+	return res
+}
@@ -0,0 +1,70 @@
+package httpfunc
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/markoczy/crawler/cli"
+	"github.com/markoczy/crawler/session"
+)
+
+func TestCheck(t *testing.T) {
+	methods := map[string][]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods[r.URL.Path] = append(methods[r.URL.Path], r.Method)
+		switch r.URL.Path {
+		case "/ok":
+			w.WriteHeader(http.StatusOK)
+		case "/get-only":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		case "/moved":
+			http.Redirect(w, r, "/gone", http.StatusMovedPermanently)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	cfg := cli.ParseArgs(flag.NewFlagSet("check", flag.ExitOnError), []string{"-url", server.URL})
+
+	tests := []struct {
+		path      string
+		status    int
+		redirects []string
+		methods   []string
+		broken    bool
+	}{
+		{"/ok", http.StatusOK, []string{}, []string{http.MethodHead}, false},
+		{"/get-only", http.StatusOK, []string{}, []string{http.MethodHead, http.MethodGet}, false},
+		{"/moved", http.StatusNotFound, []string{server.URL + "/gone"}, []string{http.MethodHead}, true},
+	}
+	for _, test := range tests {
+		res := Check(context.Background(), server.URL+test.path, cfg, session.New())
+		if res.Err != nil {
+			t.Errorf("Unexpected error at '%s': %s", test.path, res.Err.Error())
+			continue
+		}
+		if res.Status != test.status || res.Broken() != test.broken {
+			t.Errorf("Expected status %d (broken %v) at '%s' but found %d", test.status, test.broken, test.path, res.Status)
+		}
+		if !reflect.DeepEqual(res.Redirects, test.redirects) {
+			t.Errorf("Expected redirects %v at '%s' but found %v", test.redirects, test.path, res.Redirects)
+		}
+		if !reflect.DeepEqual(methods[test.path], test.methods) {
+			t.Errorf("Expected methods %v at '%s' but found %v", test.methods, test.path, methods[test.path])
+		}
+	}
+
+	// nothing listens on port 1
+	res := Check(context.Background(), "http://127.0.0.1:1/", cfg, session.New())
+	if res.Err == nil || !res.Broken() {
+		t.Errorf("Expected connection error to be a broken link")
+	}
+}
@@ -1,6 +1,7 @@
 package httpfunc
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -11,6 +12,7 @@ import (
 
 	"github.com/markoczy/crawler/cli"
 	"github.com/markoczy/crawler/logger"
+	"github.com/markoczy/crawler/session"
 )
 
 var (
@@ -18,9 +20,13 @@ var (
 	matchIllegalPathOrSep = regexp.MustCompile(`\?|\%|\*|\:|\||\"|\<|\>|\,|\;|\=|\\|/`)
 )
 
-func DownloadFile(url string, cfg cli.CrawlerConfig, log logger.Logger) error {
+// DownloadFile downloads the url to the file resolved by the naming pattern
+// and returns the amount of bytes written. Downloads larger than maxBytes
+// fail, a negative maxBytes is unlimited. Partial files of failed downloads
+// are removed
+func DownloadFile(ctx context.Context, url string, maxBytes int64, cfg cli.CrawlerConfig, sess session.Session, log logger.Logger) (int64, error) {
 	if !cfg.NamingCapture().MatchString(url) {
-		return fmt.Errorf("Cannot download: Naming Capture does not match URL string '%s'", url)
+		return 0, fmt.Errorf("Cannot download: Naming Capture does not match URL string '%s'", url)
 	}
 
 	filename := cfg.NamingPattern()
@@ -34,37 +40,93 @@ func DownloadFile(url string, cfg cli.CrawlerConfig, log logger.Logger) error {
 
 	if cfg.SkipExisting() && fileExists(filename) {
 		log.Info("Skipping download from url '%s' as local file '%s' already exists", url, filename)
-		return nil
+		return 0, nil
 	}
-	return downloadFile(url, filename, cfg.Headers())
+	return downloadFile(ctx, url, filename, maxBytes, cfg, sess, log)
 }
 
-func downloadFile(url, filename string, headers map[string]string) error {
+func downloadFile(ctx context.Context, url, filename string, maxBytes int64, cfg cli.CrawlerConfig, sess session.Session, log logger.Logger) (int64, error) {
 	var err error
-	var req *http.Request
 	var resp *http.Response
-	if req, err = http.NewRequest("GET", url, nil); err != nil {
-		return err
+	var redirects []string
+	if resp, redirects, err = Get(ctx, url, cfg, sess); err != nil {
+		return 0, err
 	}
-
-	for key, val := range headers {
-		req.Header.Set(key, val)
+	if len(redirects) > 0 {
+		log.Info("Download from url '%s' redirected: %s", url, strings.Join(redirects, " -> "))
 	}
-
-	client := &http.Client{}
-	if resp, err = client.Do(req); err != nil {
-		return err
+	if Downgraded(url, redirects) {
+		log.Warn("Download from url '%s' redirected from https to http", url)
 	}
 	defer resp.Body.Close()
 	createFolder(filename)
 
-	out, err := os.Create(filename)
+	// the body is written to a temporary file that is renamed when it is
+	// complete, so that interrupted downloads are not skipped as existing
+	tmp := filename + ".part"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return 0, err
+	}
+	n, err := copyBody(out, resp.Body, maxBytes)
+	if e2 := out.Close(); err == nil {
+		err = e2
+	}
 	if err != nil {
-		return err
+		os.Remove(tmp)
+		return n, err
+	}
+	return n, os.Rename(tmp, filename)
+}
+
+func copyBody(w io.Writer, r io.Reader, maxBytes int64) (int64, error) {
+	if maxBytes < 0 {
+		return io.Copy(w, r)
+	}
+	// one more byte than the budget tells if the body exceeds it
+	n, err := io.Copy(w, io.LimitReader(r, maxBytes+1))
+	if err == nil && n > maxBytes {
+		return n, fmt.Errorf("Download exceeds the remaining byte budget of %d bytes", maxBytes)
+	}
+	return n, err
+}
+
+// Get requests the url with the configured headers, credentials and session
+// and returns the redirects that were followed
+func Get(ctx context.Context, url string, cfg cli.CrawlerConfig, sess session.Session) (*http.Response, []string, error) {
+	redirects := NewRedirects(cfg.MaxRedirects())
+	resp, err := do(ctx, http.MethodGet, url, cfg, redirects.Client(sess.Client()))
+	return resp, redirects.URLs, err
+}
+
+func do(ctx context.Context, method, url string, cfg cli.CrawlerConfig, client *http.Client) (*http.Response, error) {
+	var err error
+	var resp *http.Response
+	if resp, err = request(ctx, method, url, cfg, client); err != nil {
+		return nil, err
+	}
+	// token may have been revoked before it expired
+	if resp.StatusCode == http.StatusUnauthorized && cfg.Credentials() != nil {
+		resp.Body.Close()
+		cfg.Credentials().Invalidate()
+		return request(ctx, method, url, cfg, client)
+	}
+	return resp, nil
+}
+
+func request(ctx context.Context, method, url string, cfg cli.CrawlerConfig, client *http.Client) (*http.Response, error) {
+	var err error
+	var req *http.Request
+	if req, err = http.NewRequestWithContext(ctx, method, url, nil); err != nil {
+		return nil, err
+	}
+	for key, val := range cfg.Headers() {
+		req.Header.Set(key, val)
+	}
+	if err = cli.Authorize(cfg, req); err != nil {
+		return nil, err
 	}
-	defer out.Close()
-	_, err = io.Copy(out, resp.Body)
-	return err
+	return client.Do(req)
 }
 
 func createFolder(filename string) error {
@@ -0,0 +1,68 @@
+package httpfunc
+
+import (
+	"context"
+	"flag"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/markoczy/crawler/cli"
+	"github.com/markoczy/crawler/logger"
+	"github.com/markoczy/crawler/session"
+)
+
+func TestDownloadMaxBytes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Repeat("x", 100)))
+	}))
+	defer server.Close()
+	dir, err := ioutil.TempDir("", "download")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %s", err.Error())
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "file")
+	cfg := cli.ParseArgs(flag.NewFlagSet("download", flag.ExitOnError), []string{"-url", server.URL})
+	log := logger.New(false, false, false)
+
+	if n, err := downloadFile(context.Background(), server.URL, filename, 50, cfg, session.New(), log); err == nil {
+		t.Errorf("Expected error when exceeding the byte budget but downloaded %d bytes", n)
+	}
+	if _, err := os.Stat(filename); !os.IsNotExist(err) {
+		t.Errorf("Expected partial download to be removed")
+	}
+	if n, err := downloadFile(context.Background(), server.URL, filename, 100, cfg, session.New(), log); err != nil || n != 100 {
+		t.Errorf("Expected download of 100 bytes but found %d: %v", n, err)
+	}
+}
+
+func TestDownloadInterrupted(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("content-length", "100")
+		w.Write([]byte(strings.Repeat("x", 10)))
+		w.(http.Flusher).Flush()
+		// closes the connection before the body is complete
+		panic(http.ErrAbortHandler)
+	}))
+	defer server.Close()
+	dir, err := ioutil.TempDir("", "download")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %s", err.Error())
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "file")
+	cfg := cli.ParseArgs(flag.NewFlagSet("download", flag.ExitOnError), []string{"-url", server.URL})
+	log := logger.New(false, false, false)
+
+	if n, err := downloadFile(context.Background(), server.URL, filename, -1, cfg, session.New(), log); err == nil {
+		t.Errorf("Expected error of interrupted download but downloaded %d bytes", n)
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 0 {
+		t.Errorf("Expected partial download to be removed but found %d files", len(files))
+	}
+}
@@ -0,0 +1,50 @@
+package httpfunc
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Redirects records the redirect chain of a request
+type Redirects struct {
+	URLs []string
+	max  int
+}
+
+// NewRedirects creates a redirect chain that fails requests after max
+// redirects or at redirect loops
+func NewRedirects(max int) *Redirects {
+	return &Redirects{URLs: []string{}, max: max}
+}
+
+// Client returns a copy of the client that records the redirects
+func (r *Redirects) Client(client *http.Client) *http.Client {
+	ret := *client
+	ret.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		u := req.URL.String()
+		for _, prev := range via {
+			if prev.URL.String() == u {
+				return fmt.Errorf("redirect loop at '%s'", u)
+			}
+		}
+		if len(via) > r.max {
+			return fmt.Errorf("stopped after %d redirects", r.max)
+		}
+		r.URLs = append(r.URLs, u)
+		return nil
+	}
+	return &ret
+}
+
+// Downgraded is true if the chain redirects from https to http
+func Downgraded(start string, chain []string) bool {
+	prev := start
+	for _, u := range chain {
+		if strings.HasPrefix(prev, "https:") && strings.HasPrefix(u, "http:") {
+			return true
+		}
+		prev = u
+	}
+	return false
+}
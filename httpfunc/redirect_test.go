@@ -0,0 +1,50 @@
+package httpfunc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestRedirects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/a":
+			http.Redirect(w, r, "/b", http.StatusMovedPermanently)
+		case "/b":
+			http.Redirect(w, r, "/c", http.StatusFound)
+		case "/loop":
+			http.Redirect(w, r, "/loop2", http.StatusFound)
+		case "/loop2":
+			http.Redirect(w, r, "/loop", http.StatusFound)
+		}
+	}))
+	defer server.Close()
+
+	redirects := NewRedirects(10)
+	resp, err := redirects.Client(http.DefaultClient).Get(server.URL + "/a")
+	if err != nil {
+		t.Fatalf("Failed to request: %s", err.Error())
+	}
+	resp.Body.Close()
+	expected := []string{server.URL + "/b", server.URL + "/c"}
+	if !reflect.DeepEqual(redirects.URLs, expected) {
+		t.Errorf("Expected redirects %v but found %v", expected, redirects.URLs)
+	}
+	if _, err = NewRedirects(1).Client(http.DefaultClient).Get(server.URL + "/a"); err == nil {
+		t.Errorf("Expected error when exceeding max redirects")
+	}
+	if _, err = NewRedirects(10).Client(http.DefaultClient).Get(server.URL + "/loop"); err == nil {
+		t.Errorf("Expected error at redirect loop")
+	}
+}
+
+func TestDowngraded(t *testing.T) {
+	if !Downgraded("https://x/a", []string{"https://x/b", "http://x/c"}) {
+		t.Errorf("Expected https to http redirect to be a downgrade")
+	}
+	if Downgraded("http://x/a", []string{"https://x/a"}) {
+		t.Errorf("Expected http to https redirect not to be a downgrade")
+	}
+}
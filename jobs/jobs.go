@@ -0,0 +1,210 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/markoczy/crawler/logger"
+	"github.com/markoczy/crawler/schedule"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	StatusOK          = "ok"
+	StatusFailed      = "failed"
+	StatusBrokenLinks = "broken-links"
+	StatusInterrupted = "interrupted"
+	stateFile         = "state.json"
+)
+
+// validName keeps job names usable as directory names
+var validName = regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
+
+// File is a jobs file, the shared flags are passed to every job before the
+// flags of the job
+type File struct {
+	// State is the directory of the job states, one sub directory per job
+	State string   `yaml:"state"`
+	Flags []string `yaml:"flags"`
+	Jobs  []*Job   `yaml:"jobs"`
+}
+
+// Job is a crawl of the seed url with the flags on a cron schedule
+type Job struct {
+	Name     string   `yaml:"name"`
+	URL      string   `yaml:"url"`
+	Schedule string   `yaml:"schedule"`
+	Flags    []string `yaml:"flags"`
+	schedule schedule.Schedule
+}
+
+// State is the state of a job and the result of its last run
+type State struct {
+	Name      string    `json:"name"`
+	Runs      int       `json:"runs"`
+	LastStart time.Time `json:"lastStart"`
+	LastEnd   time.Time `json:"lastEnd"`
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	Links     int       `json:"links"`
+	// Skipped are the runs that were due while jobs were running
+	Skipped int       `json:"skipped"`
+	NextRun time.Time `json:"nextRun"`
+}
+
+// Load reads and validates the jobs file (yaml or json)
+func Load(filename string) (*File, error) {
+	dat, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	f := &File{}
+	if err = yaml.Unmarshal(dat, f); err != nil {
+		return nil, err
+	}
+	if len(f.Jobs) == 0 {
+		return nil, fmt.Errorf("No jobs defined")
+	}
+	if f.State == "" {
+		f.State = "."
+	}
+	names := map[string]bool{}
+	for i, job := range f.Jobs {
+		if !validName.MatchString(job.Name) {
+			return nil, fmt.Errorf("Job %d requires a name of letters, digits, '.', '_' and '-'", i+1)
+		}
+		if names[job.Name] {
+			return nil, fmt.Errorf("Duplicate job name '%s'", job.Name)
+		}
+		names[job.Name] = true
+		if job.URL == "" {
+			return nil, fmt.Errorf("Job '%s' requires an url", job.Name)
+		}
+		if job.schedule, err = schedule.Parse(job.Schedule); err != nil {
+			return nil, fmt.Errorf("Job '%s': %s", job.Name, err.Error())
+		}
+		if job.schedule.Next(time.Now()).IsZero() {
+			return nil, fmt.Errorf("Job '%s' is never scheduled by '%s'", job.Name, job.Schedule)
+		}
+	}
+	return f, nil
+}
+
+// Args returns the crawl arguments of the job
+func (f *File) Args(job *Job) []string {
+	args := append([]string{}, f.Flags...)
+	args = append(args, job.Flags...)
+	return append(args, "-url", job.URL)
+}
+
+// Dir returns the state directory of the job
+func (f *File) Dir(job *Job) string {
+	return filepath.Join(f.State, job.Name)
+}
+
+// LoadState reads the state of the job, the state is empty before the first
+// run
+func (f *File) LoadState(job *Job) (*State, error) {
+	st := &State{Name: job.Name}
+	dat, err := ioutil.ReadFile(filepath.Join(f.Dir(job), stateFile))
+	if os.IsNotExist(err) {
+		return st, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(dat, st); err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+// SaveState writes the state of the job
+func (f *File) SaveState(job *Job, st *State) error {
+	if err := os.MkdirAll(f.Dir(job), 0755); err != nil {
+		return err
+	}
+	dat, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(f.Dir(job), stateFile), dat, 0644)
+}
+
+// Run runs the jobs on schedule until the context is canceled. Jobs run one
+// at a time, a job due while another job runs starts after it. Runs of a job
+// that are due while the job is still running are skipped.
+func (f *File) Run(ctx context.Context, log logger.Logger, run func(job *Job, st *State)) {
+	next := map[*Job]time.Time{}
+	now := time.Now()
+	for _, job := range f.Jobs {
+		next[job] = job.schedule.Next(now)
+		log.Info("Job '%s' scheduled at %s", job.Name, next[job].Format(time.RFC3339))
+	}
+	for {
+		// the earliest job, jobs due at the same time run in file order
+		var job *Job
+		for _, j := range f.Jobs {
+			if job == nil || next[j].Before(next[job]) {
+				job = j
+			}
+		}
+		timer := time.NewTimer(time.Until(next[job]))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		st, err := f.LoadState(job)
+		if err != nil {
+			log.Warn("Failed to load state of job '%s', starting with empty state: %s", job.Name, err.Error())
+			st = &State{Name: job.Name}
+		}
+		st.Runs++
+		st.LastStart = time.Now()
+		st.Error = ""
+		log.Warn("Running job '%s' (scheduled at %s)", job.Name, next[job].Format(time.RFC3339))
+		run(job, st)
+		st.LastEnd = time.Now()
+
+		skipped := 0
+		for t := job.schedule.Next(next[job]); !t.IsZero() && !t.After(st.LastEnd); t = job.schedule.Next(t) {
+			skipped++
+		}
+		if skipped > 0 {
+			log.Warn("Job '%s' skipped %d runs that were due while jobs were running", job.Name, skipped)
+		}
+		st.Skipped += skipped
+		next[job] = job.schedule.Next(st.LastEnd)
+		st.NextRun = next[job]
+		log.Warn("Finished job '%s' with status '%s' in %s, next run at %s", job.Name, st.Status, st.LastEnd.Sub(st.LastStart).Round(time.Second), st.NextRun.Format(time.RFC3339))
+		if err = f.SaveState(job, st); err != nil {
+			log.Error("Failed to save state of job '%s': %s", job.Name, err.Error())
+		}
+		if next[job].IsZero() {
+			log.Warn("Job '%s' is not scheduled anymore", job.Name)
+			delete(next, job)
+			f.Jobs = remove(f.Jobs, job)
+			if len(f.Jobs) == 0 {
+				return
+			}
+		}
+	}
+}
+
+func remove(jobs []*Job, job *Job) []*Job {
+	ret := []*Job{}
+	for _, j := range jobs {
+		if j != job {
+			ret = append(ret, j)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,70 @@
+package jobs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, dir, content string) string {
+	filename := filepath.Join(dir, "jobs.yaml")
+	if err := ioutil.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write jobs file: %s", err.Error())
+	}
+	return filename
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jobs")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %s", err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	f, err := Load(writeFile(t, dir, `
+state: `+dir+`
+flags: ["-renderer", "http"]
+jobs:
+  - name: wiki
+    url: http://wiki/
+    schedule: "0 2 * * *"
+    flags: ["-depth", "3"]
+`))
+	if err != nil {
+		t.Fatalf("Failed to load jobs: %s", err.Error())
+	}
+	job := f.Jobs[0]
+	expected := []string{"-renderer", "http", "-depth", "3", "-url", "http://wiki/"}
+	if args := f.Args(job); !reflect.DeepEqual(args, expected) {
+		t.Errorf("Expected args %v but found %v", expected, args)
+	}
+
+	st, err := f.LoadState(job)
+	if err != nil || st.Runs != 0 {
+		t.Fatalf("Expected empty state before first run: %v", err)
+	}
+	st.Runs, st.Status, st.LastStart = 1, StatusOK, time.Date(2021, 3, 5, 2, 0, 0, 0, time.UTC)
+	if err = f.SaveState(job, st); err != nil {
+		t.Fatalf("Failed to save state: %s", err.Error())
+	}
+	loaded, err := f.LoadState(job)
+	if err != nil || !reflect.DeepEqual(loaded, st) {
+		t.Errorf("Expected state %+v but found %+v (%v)", st, loaded, err)
+	}
+
+	invalid := []string{
+		`jobs: []`,
+		`jobs: [{name: "a b", url: "http://a/", schedule: "@daily"}]`,
+		`jobs: [{name: a, url: "http://a/", schedule: "@daily"}, {name: a, url: "http://b/", schedule: "@daily"}]`,
+		`jobs: [{name: a, schedule: "@daily"}]`,
+		`jobs: [{name: a, url: "http://a/", schedule: "61 * * * *"}]`,
+	}
+	for _, content := range invalid {
+		if _, err = Load(writeFile(t, dir, content)); err == nil {
+			t.Errorf("Expected error for jobs file '%s'", content)
+		}
+	}
+}
@@ -30,6 +30,132 @@ function getLinks() {
     return array;
 }`
 
+const GetCanonical = `getCanonical();
+function getCanonical() {
+    if (!document) return "";
+    var link = document.querySelector("link[rel=canonical]");
+    return link && link.href ? link.href : "";
+}`
+
+const GetText = `getText();
+function getText() {
+    if (!document || !document.body) return "";
+    return document.body.innerText || "";
+}`
+
+const GetMetadata = `getMetadata();
+function getMetadata() {
+    var meta = {title: "", description: "", robots: "", canonical: "", hreflang: {}, og: {}, h1: [], h2: [], h3: [], wordCount: 0, nofollow: []};
+    if (!document) return meta;
+    meta.title = document.title || "";
+    for (var el of document.querySelectorAll("meta")) {
+        var name = (el.getAttribute("name") || "").toLowerCase();
+        var property = (el.getAttribute("property") || "").toLowerCase();
+        var content = el.getAttribute("content") || "";
+        if (name === "description") meta.description = content;
+        if (name === "robots") meta.robots = content;
+        if (property.indexOf("og:") === 0) meta.og[property.substring(3)] = content;
+    }
+    var canonical = document.querySelector("link[rel=canonical]");
+    if (canonical && canonical.href) meta.canonical = canonical.href;
+    for (var el of document.querySelectorAll("link[rel=alternate][hreflang]")) {
+        meta.hreflang[el.getAttribute("hreflang")] = el.href;
+    }
+    for (var tag of ["h1", "h2", "h3"]) {
+        for (var el of document.querySelectorAll(tag)) {
+            meta[tag].push((el.innerText || "").trim());
+        }
+    }
+    var text = document.body ? document.body.innerText || "" : "";
+    meta.wordCount = text.split(/\s+/).filter(function(word) { return word; }).length;
+    for (var el of document.querySelectorAll("a[href]")) {
+        var rel = (el.getAttribute("rel") || "").toLowerCase().split(/\s+/);
+        if (rel.indexOf("nofollow") >= 0) meta.nofollow.push(el.href);
+    }
+    return meta;
+}`
+
+// GetStructuredData returns the json-ld blocks as text and the microdata and
+// rdfa items with a list of values per property, see structured.Raw
+const GetStructuredData = `getStructuredData();
+function elementValue(el) {
+    var tag = el.tagName.toLowerCase();
+    if (el.hasAttribute("content")) return el.getAttribute("content");
+    if (el.hasAttribute("href") && typeof el.href === 'string') return el.href;
+    if (el.hasAttribute("src") && typeof el.src === 'string') return el.src;
+    if (tag === "object" && el.data) return el.data;
+    if (tag === "time" && el.hasAttribute("datetime")) return el.getAttribute("datetime");
+    if ((tag === "data" || tag === "meter") && el.hasAttribute("value")) return el.getAttribute("value");
+    return (el.textContent || "").trim();
+}
+function tokens(value) {
+    return (value || "").split(/\s+/).filter(function(t) { return t; });
+}
+function microdataItem(scope) {
+    var item = {format: "microdata", type: tokens(scope.getAttribute("itemtype")), id: scope.getAttribute("itemid") || "", properties: {}};
+    for (var el of scope.querySelectorAll("[itemprop]")) {
+        // properties of nested items belong to the nested item
+        if (el.parentElement.closest("[itemscope]") !== scope) continue;
+        var value = el.hasAttribute("itemscope") ? microdataItem(el) : elementValue(el);
+        for (var name of tokens(el.getAttribute("itemprop"))) {
+            (item.properties[name] = item.properties[name] || []).push(value);
+        }
+    }
+    return item;
+}
+function rdfaItem(scope) {
+    var item = {format: "rdfa", type: tokens(scope.getAttribute("typeof")), id: scope.getAttribute("resource") || scope.getAttribute("about") || "", properties: {}};
+    var vocab = scope.closest("[vocab]");
+    if (vocab) {
+        item.type = item.type.map(function(t) { return t.indexOf(":") < 0 ? vocab.getAttribute("vocab") + t : t; });
+    }
+    for (var el of scope.querySelectorAll("[property]")) {
+        if (el.parentElement.closest("[typeof]") !== scope) continue;
+        var value = el.hasAttribute("typeof") ? rdfaItem(el) : elementValue(el);
+        for (var name of tokens(el.getAttribute("property"))) {
+            (item.properties[name] = item.properties[name] || []).push(value);
+        }
+    }
+    return item;
+}
+function getStructuredData() {
+    var data = {jsonld: [], items: []};
+    if (!document) return data;
+    for (var el of document.querySelectorAll("script[type='application/ld+json']")) {
+        data.jsonld.push(el.textContent || "");
+    }
+    for (var el of document.querySelectorAll("[itemscope]:not([itemprop])")) {
+        data.items.push(microdataItem(el));
+    }
+    for (var el of document.querySelectorAll("[typeof]:not([property])")) {
+        data.items.push(rdfaItem(el));
+    }
+    return data;
+}`
+
+// Extract is called with the fields of an extraction schema and returns the
+// values per field name, href and src are read as properties so that they
+// are absolute urls
+const Extract = `function(fields) {
+    var values = {};
+    for (var field of fields) {
+        values[field.name] = [];
+        if (!document) continue;
+        for (var el of document.querySelectorAll(field.selector)) {
+            var value;
+            if (!field.attribute) {
+                value = (el.innerText || el.textContent || "").trim();
+            } else if ((field.attribute === "href" || field.attribute === "src") && typeof el[field.attribute] === 'string') {
+                value = el[field.attribute];
+            } else {
+                value = el.getAttribute(field.attribute);
+            }
+            if (value !== null && value !== undefined) values[field.name].push(value);
+        }
+    }
+    return values;
+}`
+
 func CreateWaitFunc(d time.Duration) *rod.EvalOptions {
 	millis := d / time.Millisecond
 	return &rod.EvalOptions{
@@ -1,37 +1,100 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/go-rod/rod"
-	"github.com/ysmood/gson"
+	"github.com/go-rod/rod/lib/launcher"
+	"github.com/go-rod/rod/lib/launcher/flags"
+	"github.com/go-rod/rod/lib/proto"
 
-	// "context"
+	"github.com/markoczy/crawler/backend"
+	"github.com/markoczy/crawler/block"
+	"github.com/markoczy/crawler/budget"
 	"github.com/markoczy/crawler/cli"
+	"github.com/markoczy/crawler/emulation"
+	"github.com/markoczy/crawler/errclass"
+	"github.com/markoczy/crawler/extract"
 	"github.com/markoczy/crawler/httpfunc"
+	"github.com/markoczy/crawler/jobs"
 	"github.com/markoczy/crawler/js"
 	"github.com/markoczy/crawler/logger"
+	"github.com/markoczy/crawler/meta"
+	"github.com/markoczy/crawler/output"
+	"github.com/markoczy/crawler/proxy"
+	"github.com/markoczy/crawler/search"
+	"github.com/markoczy/crawler/session"
+	"github.com/markoczy/crawler/simhash"
+	"github.com/markoczy/crawler/snapshot"
+	"github.com/markoczy/crawler/structured"
+	"github.com/markoczy/crawler/trap"
 	"github.com/markoczy/crawler/types"
+	"github.com/markoczy/crawler/urlnorm"
+)
+
+const (
+	sourceSeed      = "seed"
+	sourceDom       = "dom"
+	sourceNetwork   = "network"
+	sourceCanonical = "canonical"
+	sourceRedirect  = "redirect"
+
+	// exitInterrupted is the exit code of a crawl stopped by a signal
+	exitInterrupted = 130
+	// exitBrokenLinks is the exit code of a link check with broken links
+	exitBrokenLinks = 2
 )
 
 var (
 	browser *rod.Browser
-	router  *rod.HijackRouter
+	remote  bool
+	// blocker has the blocking rules of the crawl, each page counts the
+	// blocked requests with its own copy
+	blocker block.Blocker
+	sess    = session.New()
 	log     logger.Logger
+	// rootCtx is canceled on SIGINT or SIGTERM and stops the crawl
+	rootCtx, cancelRoot = context.WithCancel(context.Background())
+	exitCode            = 0
+	// keepBrowser is set by the daemon to reuse the browser for all jobs
+	keepBrowser = false
+	// browserKey is the launch key of the connected browser
+	browserKey string
 
-	validConnectErrs = []string{
-		"unsupported protocol scheme",
-		"no data of the requested type was found",
-		"context canceled",
-	}
+	// snapshotKey normalizes the urls of snapshots, so that snapshots are
+	// comparable with and without '-normalize'
+	snapshotKey = urlnorm.New(nil, true)
+
+	// frameKey normalizes the urls of navigations, so that the url requested
+	// by the browser matches the url passed to it
+	frameKey = urlnorm.New(nil, false)
+
+	findJSONUrls = regexp.MustCompile(`https?://[^\s"'<>\\]+`)
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == cli.SearchCommand {
+		runSearch(cli.ParseSearchFlags(os.Args[2:]))
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == cli.DaemonCommand {
+		runDaemon(cli.ParseDaemonFlags(os.Args[2:]))
+		return
+	}
 	cfg := cli.ParseFlags()
 	if log == nil {
 		// logger may be initialized before (test scope)
@@ -42,29 +105,454 @@ func main() {
 		test(cfg)
 		return
 	}
-	exec(cfg)
+	handleSignals()
+	if _, err := exec(cfg, os.Stdout); err != nil {
+		log.Error("%s", err.Error())
+	}
+	if rootCtx.Err() != nil {
+		exitCode = exitInterrupted
+	}
+	if exitCode != 0 {
+		os.Exit(exitCode)
+	}
+}
+
+// handleSignals cancels the root context on the first signal, the second
+// signal exits immediately
+func handleSignals() {
+	sigs := make(chan os.Signal, 2)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-sigs
+		log.Warn("Received %s, stopping crawl (repeat to exit immediately)", sig.String())
+		cancelRoot()
+		sig = <-sigs
+		log.Warn("Received %s, exiting", sig.String())
+		os.Exit(exitInterrupted)
+	}()
+}
+
+// runSearch queries the index and writes the ranked results
+func runSearch(cfg cli.SearchConfig) {
+	if log == nil {
+		log = logger.New(true, false, false)
+	}
+	if _, err := os.Stat(cfg.Index()); err != nil {
+		log.Error("Failed to open index '%s': %s", cfg.Index(), err.Error())
+		os.Exit(1)
+	}
+	idx, err := search.Open(cfg.Index())
+	if err != nil {
+		log.Error("Failed to open index '%s': %s", cfg.Index(), err.Error())
+		os.Exit(1)
+	}
+	results := idx.Search(cfg.Query(), cfg.Limit())
+	if cfg.Output() == output.FormatJSONL {
+		enc := json.NewEncoder(os.Stdout)
+		for _, res := range results {
+			enc.Encode(res)
+		}
+		return
+	}
+	for _, res := range results {
+		fmt.Printf("%s\t%.3f\n\t%s\n", res.URL, res.Score, res.Snippet)
+	}
+}
+
+// runDaemon runs the jobs on schedule until SIGINT or SIGTERM, the browser
+// is launched by the first job that needs it and reused by all jobs, jobs
+// with invalid flags are logged and not run
+func runDaemon(cfg cli.DaemonConfig, err error) {
+	if log == nil {
+		log = logger.New(cfg.LogWarn(), cfg.LogInfo(), cfg.LogDebug())
+	}
+	if err != nil {
+		log.Error("%s", err.Error())
+	}
+	log.Info("Parsed Params: %s", cfg.String())
+	if len(cfg.Jobs().Jobs) == 0 {
+		log.Error("No valid jobs to run")
+		os.Exit(1)
+	}
+	handleSignals()
+	keepBrowser = true
+	defer disconnect()
+	cfg.Jobs().Run(rootCtx, log, func(job *jobs.Job, st *jobs.State) {
+		runJob(cfg.Jobs(), job, cfg.Crawl(job), st)
+	})
+	log.Warn("Daemon stopped")
+}
+
+// runJob runs the crawl of a job with a new session and writes the output
+// of the last run to the state directory of the job
+func runJob(file *jobs.File, job *jobs.Job, cfg cli.CrawlerConfig, st *jobs.State) {
+	sess = session.New()
+	exitCode = 0
+	st.Status, st.Links = jobs.StatusFailed, 0
+	if err := os.MkdirAll(file.Dir(job), 0755); err != nil {
+		st.Error = err.Error()
+		log.Error("Failed to create state directory of job '%s': %s", job.Name, err.Error())
+		return
+	}
+	filename := filepath.Join(file.Dir(job), "output."+cfg.Output())
+	out, err := os.Create(filename)
+	if err != nil {
+		st.Error = err.Error()
+		log.Error("Failed to create output of job '%s': %s", job.Name, err.Error())
+		return
+	}
+	defer out.Close()
+	if st.Links, err = exec(cfg, out); err != nil {
+		st.Error = err.Error()
+		log.Error("Job '%s': %s", job.Name, err.Error())
+		return
+	}
+	switch {
+	case rootCtx.Err() != nil:
+		st.Status = jobs.StatusInterrupted
+	case exitCode == exitBrokenLinks:
+		st.Status = jobs.StatusBrokenLinks
+	default:
+		st.Status = jobs.StatusOK
+	}
 }
 
 func test(cfg cli.CrawlerConfig) {
 	// TODO
 }
 
-func exec(cfg cli.CrawlerConfig) {
-	reconnect(cfg)
-	defer disconnect()
+// exec runs the crawl and writes the results to w, returns the amount of
+// found links or an error if the crawl could not start
+func exec(cfg cli.CrawlerConfig, w io.Writer) (int, error) {
+	if cfg.CookiesImport() != "" {
+		if err := sess.Import(cfg.CookiesImport()); err != nil {
+			return 0, fmt.Errorf("Failed to import cookies from '%s': %s", cfg.CookiesImport(), err.Error())
+		}
+		log.Info("Imported %d cookies from '%s'", sess.Len(), cfg.CookiesImport())
+	}
+	if cfg.CookiesExport() != "" {
+		defer exportCookies(cfg)
+	}
+	if cfg.Credentials() != nil {
+		// jobs of the daemon obtain their first token when they run
+		if _, err := cfg.Credentials().Header(); err != nil {
+			return 0, fmt.Errorf("Failed to obtain token: %s", err.Error())
+		}
+	}
+	if len(cfg.Proxies()) > 0 {
+		pool, err := proxy.New(cfg.Proxies(), cfg.ProxyRotation(), cfg.ProxyMaxFailures(), log)
+		if err != nil {
+			return 0, fmt.Errorf("Failed to create proxy pool: %s", err.Error())
+		}
+		sess.SetTransport(pool)
+	}
+	// the http renderer only needs a browser to log in
+	if cfg.Renderer() != cli.RendererHTTP || cfg.LoginUrl() != "" {
+		// the daemon keeps the browser open for the next job
+		if !keepBrowser {
+			defer disconnect()
+		}
+		if err := connect(cfg); err != nil {
+			return 0, fmt.Errorf("Failed to connect to browser: %s", err.Error())
+		}
+	}
+
+	if cfg.LoginUrl() != "" {
+		if err := login(cfg); err != nil {
+			return 0, fmt.Errorf("Login at url '%s' failed: %s", cfg.LoginUrl(), err.Error())
+		}
+	}
 
-	links := getAllLinks(cfg).Values()
+	out, err := output.New(cfg.Output(), w)
+	if err != nil {
+		return 0, fmt.Errorf("Failed to create output: %s", err.Error())
+	}
+	state := newCrawlState(cfg)
+	var cancel context.CancelFunc
+	if cfg.Limits().Duration > 0 {
+		state.ctx, cancel = context.WithTimeout(rootCtx, cfg.Limits().Duration)
+	} else {
+		state.ctx, cancel = context.WithCancel(rootCtx)
+	}
+	defer cancel()
+	if cfg.Records() != "" {
+		file, err := os.Create(cfg.Records())
+		if err != nil {
+			return 0, fmt.Errorf("Failed to create records file '%s': %s", cfg.Records(), err.Error())
+		}
+		defer file.Close()
+		if state.records, err = extract.NewWriter(cfg.RecordsFormat(), file, cfg.Schemas()); err != nil {
+			return 0, fmt.Errorf("Failed to create records writer: %s", err.Error())
+		}
+	}
+	if cfg.Index() != "" {
+		if state.index, err = search.Open(cfg.Index()); err != nil {
+			return 0, fmt.Errorf("Failed to open index '%s': %s", cfg.Index(), err.Error())
+		}
+		// the pages visited so far are indexed on interrupts as well
+		defer saveIndex(cfg, state.index)
+	}
+	all := getAllLinks(cfg, state)
+	if rootCtx.Err() != nil && !cfg.Download() {
+		log.Warn("Crawl interrupted, writing %d links found so far", all.Len())
+	}
+	links := all.Values()
 	sort.Strings(links)
+	if cfg.CheckLinks() {
+		if checkLinks(cfg, state, links, out) > 0 {
+			exitCode = exitBrokenLinks
+		}
+		links = []string{}
+	}
 	for _, link := range links {
 		if cfg.Download() {
+			if rootCtx.Err() != nil {
+				log.Warn("Crawl interrupted, skipping remaining downloads")
+				break
+			}
+			if state.budget.Done() {
+				log.Warn("Budget exhausted, skipping remaining downloads")
+				break
+			}
 			log.Info("Downloading from URL '%s'", link)
-			if err := httpfunc.DownloadFile(link, cfg, log); err != nil {
+			n, err := httpfunc.DownloadFile(state.ctx, link, state.budget.RemainingBytes(), cfg, sess, log)
+			state.budget.AddBytes(n)
+			if err != nil {
 				log.Error("Failed to download content at url '%s': %s", link, err.Error())
 			}
 		} else {
-			fmt.Println(link)
+			found := state.sources.Get(link)
+			sort.Strings(found)
+			if err := out.WriteLink(output.Link{URL: link, Sources: found, Redirects: state.redirects[link]}); err != nil {
+				log.Error("Failed to write link '%s': %s", link, err.Error())
+			}
+		}
+	}
+	// text output only lists pages with metadata, structured data needs jsonl
+	if cfg.Output() == output.FormatJSONL || cfg.Metadata() {
+		writePages(out, state.pages)
+	}
+	if cfg.Snapshot() != "" {
+		for _, link := range all.Values() {
+			state.snapshot.Links = append(state.snapshot.Links, snapshotKey.Normalize(link))
+		}
+		compareSnapshot(cfg, state)
+	}
+	writeTraps(cfg, state.traps.Traps())
+	writeClusters(cfg, state.duplicates.Clusters())
+	if hit := state.budget.Hit(); len(hit) > 0 {
+		log.Warn("Budget limits reached: %s", strings.Join(hit, ", "))
+	}
+	return all.Len(), nil
+}
+
+// checkLinks checks the status of all links and writes the results grouped
+// by the pages the links were found on, returns the amount of broken links
+func checkLinks(cfg cli.CrawlerConfig, state *crawlState, links []string, out output.Writer) int {
+	results := map[string]*httpfunc.CheckResult{}
+	pages := map[string][]string{}
+	broken := 0
+	for _, link := range links {
+		if rootCtx.Err() != nil {
+			log.Warn("Crawl interrupted, skipping remaining link checks")
+			break
+		}
+		if state.ctx.Err() != nil {
+			log.Warn("Budget exhausted, skipping remaining link checks")
+			break
+		}
+		log.Info("Checking link '%s'", link)
+		res := httpfunc.Check(state.ctx, link, cfg, sess)
+		if res.Broken() {
+			broken++
+			log.Warn("Broken link '%s': %s", link, checkStatus(res))
+		}
+		results[link] = res
+		// seeds are grouped without page
+		referrers := state.referrers.Get(link)
+		if len(referrers) == 0 {
+			referrers = []string{""}
+		}
+		for _, page := range referrers {
+			pages[page] = append(pages[page], link)
+		}
+	}
+	sorted := []string{}
+	for page := range pages {
+		sorted = append(sorted, page)
+	}
+	sort.Strings(sorted)
+	for _, page := range sorted {
+		check := output.PageCheck{Page: page, Links: []output.CheckedLink{}}
+		for _, link := range pages[page] {
+			res := results[link]
+			checked := output.CheckedLink{
+				URL:       link,
+				Status:    res.Status,
+				Redirects: res.Redirects,
+				Broken:    res.Broken(),
+			}
+			if res.Err != nil {
+				checked.Error = res.Err.Error()
+			}
+			check.Links = append(check.Links, checked)
+		}
+		if err := out.WriteCheck(check); err != nil {
+			log.Error("Failed to write link check of page '%s': %s", page, err.Error())
 		}
 	}
+	log.Warn("Checked %d links, found %d broken links", len(results), broken)
+	return broken
+}
+
+func checkStatus(res *httpfunc.CheckResult) string {
+	if res.Err != nil {
+		return res.Err.Error()
+	}
+	return fmt.Sprintf("status %d", res.Status)
+}
+
+func saveIndex(cfg cli.CrawlerConfig, idx search.Index) {
+	if err := idx.Save(); err != nil {
+		log.Error("Failed to save index '%s': %s", cfg.Index(), err.Error())
+		return
+	}
+	log.Info("Saved %d pages to index '%s'", idx.Len(), cfg.Index())
+}
+
+// writePages writes the visited pages sorted by url
+func writePages(out output.Writer, pages map[string]output.Page) {
+	urls := []string{}
+	for u := range pages {
+		urls = append(urls, u)
+	}
+	sort.Strings(urls)
+	for _, u := range urls {
+		if err := out.WritePage(pages[u]); err != nil {
+			log.Error("Failed to write page '%s': %s", u, err.Error())
+		}
+	}
+}
+
+// writeTraps writes the traps to the trap report or logs them
+func writeTraps(cfg cli.CrawlerConfig, traps map[string]string) {
+	if len(traps) == 0 {
+		return
+	}
+	log.Warn("Found %d crawler traps", len(traps))
+	links := []string{}
+	for link := range traps {
+		links = append(links, link)
+	}
+	sort.Strings(links)
+	if cfg.TrapReport() == "" {
+		for _, link := range links {
+			log.Warn("Trap '%s': %s", link, traps[link])
+		}
+		return
+	}
+	writeReport(cfg, cfg.TrapReport(), func(out output.Writer) error {
+		for _, link := range links {
+			if err := out.WriteTrap(output.Trap{URL: link, Reason: traps[link]}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// writeClusters writes the clusters of near-duplicate pages to the duplicate
+// report or logs them
+func writeClusters(cfg cli.CrawlerConfig, clusters [][]string) {
+	if len(clusters) == 0 {
+		return
+	}
+	log.Warn("Found %d clusters of near-duplicate pages", len(clusters))
+	if cfg.DuplicateReport() == "" {
+		for _, cluster := range clusters {
+			log.Warn("Near-duplicates of '%s': %v", cluster[0], cluster[1:])
+		}
+		return
+	}
+	writeReport(cfg, cfg.DuplicateReport(), func(out output.Writer) error {
+		for _, cluster := range clusters {
+			if err := out.WriteCluster(output.Cluster{URLs: cluster}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// compareSnapshot writes the changes to the previous snapshot to the change
+// report or logs them and replaces the snapshot
+func compareSnapshot(cfg cli.CrawlerConfig, state *crawlState) {
+	cur := state.snapshot
+	// removed urls of a partial crawl would be false alarms
+	if rootCtx.Err() != nil {
+		log.Warn("Crawl interrupted, not comparing and replacing snapshot '%s'", cfg.Snapshot())
+		return
+	}
+	if hit := state.budget.Hit(); len(hit) > 0 {
+		log.Warn("Budget limits reached (%s), not comparing and replacing snapshot '%s'", strings.Join(hit, ", "), cfg.Snapshot())
+		return
+	}
+	if state.failedSeeds.Len() > 0 {
+		failed := state.failedSeeds.Values()
+		sort.Strings(failed)
+		log.Warn("Failed to crawl seed urls %v, not comparing and replacing snapshot '%s'", failed, cfg.Snapshot())
+		return
+	}
+	prev, err := snapshot.Load(cfg.Snapshot())
+	if err != nil {
+		log.Error("Failed to load snapshot '%s': %s", cfg.Snapshot(), err.Error())
+		return
+	}
+	if prev == nil {
+		log.Info("No previous snapshot at '%s'", cfg.Snapshot())
+	} else {
+		changes := snapshot.Compare(prev, cur)
+		log.Warn("Found %d changes since %s", len(changes), prev.Time.Format(time.RFC3339))
+		if cfg.ChangeReport() == "" {
+			for _, change := range changes {
+				log.Warn("Url '%s' %s", change.URL, change.Kind)
+				if change.Diff != "" {
+					log.Info("Diff of '%s':\n%s", change.URL, change.Diff)
+				}
+			}
+		} else {
+			writeReport(cfg, cfg.ChangeReport(), func(out output.Writer) error {
+				for _, change := range changes {
+					if err := out.WriteChange(output.Change{URL: change.URL, Change: change.Kind, Diff: change.Diff}); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+		}
+	}
+	if err = cur.Save(cfg.Snapshot()); err != nil {
+		log.Error("Failed to save snapshot '%s': %s", cfg.Snapshot(), err.Error())
+	}
+}
+
+// writeReport creates the file and writes a report in the selected output
+// format
+func writeReport(cfg cli.CrawlerConfig, filename string, write func(out output.Writer) error) {
+	file, err := os.Create(filename)
+	if err != nil {
+		log.Error("Failed to create report '%s': %s", filename, err.Error())
+		return
+	}
+	defer file.Close()
+	out, err := output.New(cfg.Output(), file)
+	if err != nil {
+		log.Error("Failed to create output: %s", err.Error())
+		return
+	}
+	if err = write(out); err != nil {
+		log.Error("Failed to write report '%s': %s", filename, err.Error())
+	}
 }
 
 // Helpers
@@ -77,17 +565,124 @@ func check(err error) {
 
 // Maybe outsource
 
-func getAllLinks(cfg cli.CrawlerConfig) *types.StringSet {
+// crawlState is shared by all recursive calls of a crawl, visited, backend
+// and profile are set per profile
+type crawlState struct {
+	visited   *types.Tracker
+	sources   *types.LinkSources
+	referrers *types.LinkSources
+	redirects map[string][]string
+	pages     map[string]output.Page
+	snapshot  *snapshot.Snapshot
+	// failedSeeds are the seed urls that could not be crawled, the set is
+	// shared by the crawls of all profiles
+	failedSeeds *types.StringSet
+	// ctx is canceled on interrupts and when the max duration is over, so
+	// that running navigations and downloads stop
+	ctx        context.Context
+	index      search.Index
+	records    extract.Writer
+	budget     budget.Budget
+	traps      trap.Detector
+	duplicates simhash.Index
+	backend    backend.Backend
+	profile    *emulation.Profile
+}
+
+func newCrawlState(cfg cli.CrawlerConfig) *crawlState {
+	return &crawlState{
+		sources:     types.NewLinkSources(),
+		referrers:   types.NewLinkSources(),
+		redirects:   map[string][]string{},
+		pages:       map[string]output.Page{},
+		snapshot:    snapshot.New(),
+		failedSeeds: types.NewStringSet(),
+		budget:      budget.New(cfg.Limits()),
+		traps:       trap.New(cfg.TrapLimits()),
+		duplicates:  simhash.NewIndex(cfg.NearDuplicateDistance()),
+	}
+}
+
+// source tags the source with the emulated profile
+func (state *crawlState) source(s string) string {
+	if state.profile == nil {
+		return s
+	}
+	return s + "@" + state.profile.Name
+}
+
+// browserBackend renders pages with the rod browser
+type browserBackend struct {
+	cfg     cli.CrawlerConfig
+	profile *emulation.Profile
+}
+
+func (b *browserBackend) GetPage(ctx context.Context, url string) (*backend.Page, error) {
+	return getLinks(ctx, b.cfg, url, b.profile)
+}
+
+func newBackend(cfg cli.CrawlerConfig, profile *emulation.Profile) backend.Backend {
+	switch cfg.Renderer() {
+	case cli.RendererHTTP:
+		return backend.NewHTTP(cfg, sess, log)
+	case cli.RendererAuto:
+		// extraction schemas are evaluated on the rendered page
+		force := func(url string) bool {
+			return len(extract.Match(cfg.Schemas(), url)) > 0
+		}
+		return backend.NewAuto(backend.NewHTTP(cfg, sess, log), &browserBackend{cfg: cfg, profile: profile}, force, log)
+	default:
+		return &browserBackend{cfg: cfg, profile: profile}
+	}
+}
+
+func getAllLinks(cfg cli.CrawlerConfig, shared *crawlState) *types.StringSet {
+	allLinks := types.NewStringSet()
+	// the crawl runs once per profile, nil runs without emulation
+	profiles := []*emulation.Profile{nil}
+	if len(cfg.Profiles()) > 0 {
+		profiles = []*emulation.Profile{}
+		for i := range cfg.Profiles() {
+			profiles = append(profiles, &cfg.Profiles()[i])
+		}
+	}
+	for _, profile := range profiles {
+		if profile != nil {
+			log.Info("Crawling with profile '%s'", profile.Name)
+		}
+		state := *shared
+		state.visited = types.NewTracker()
+		state.backend = newBackend(cfg, profile)
+		state.profile = profile
+		allLinks.Add(crawl(cfg, &state).Values()...)
+	}
+	// traps are reported separately
+	for link := range shared.traps.Traps() {
+		allLinks.Remove(link)
+	}
+	return allLinks
+}
+
+func crawl(cfg cli.CrawlerConfig, state *crawlState) *types.StringSet {
 	allLinks := types.NewStringSet()
-	allVisited := types.NewTracker()
 	for _, perm := range cfg.Urls() {
-		links := getLinksRecursive(cfg, perm, 0, allVisited)
+		perm = cfg.Normalizer().Normalize(perm)
+		if !state.budget.Link(perm) {
+			break
+		}
+		state.sources.Add(perm, state.source(sourceSeed))
+		links := getLinksRecursive(cfg, perm, 0, state)
 		for _, link := range links.Values() {
 			if !cfg.Include().MatchString(link) || cfg.Exclude().MatchString(link) {
 				log.Info("Not including '%s': URL not matching include or matching exclude pattern", link)
 				links.Remove(link)
 				continue
 			}
+			if !matchSources(cfg, state.sources.Get(link)) {
+				log.Info("Not including '%s': Sources %v not matching source-include or matching source-exclude pattern", link, state.sources.Get(link))
+				links.Remove(link)
+				continue
+			}
 			log.Info("Found Link '%s'", link)
 		}
 		allLinks.Add(links.Values()...)
@@ -95,78 +690,278 @@ func getAllLinks(cfg cli.CrawlerConfig) *types.StringSet {
 	return allLinks
 }
 
-func getLinksRecursive(cfg cli.CrawlerConfig, url string, depth int, visited *types.Tracker) *types.StringSet {
+func matchSources(cfg cli.CrawlerConfig, sources []string) bool {
+	for _, source := range sources {
+		if cfg.SourceInclude().MatchString(source) && !cfg.SourceExclude().MatchString(source) {
+			return true
+		}
+	}
+	return false
+}
+
+func getLinksRecursive(cfg cli.CrawlerConfig, url string, depth int, state *crawlState) *types.StringSet {
 	ret := types.NewStringSet()
 	ret.Add(url)
+	// exit condition 0: crawl interrupted, the url is kept as it was found
+	if rootCtx.Err() != nil {
+		return ret
+	}
 	// exit condition 1: over depth (download mode has depth-1)
 	if depth > cfg.Depth() || (cfg.Download() && depth > cfg.Depth()-1) {
 		log.Debug("Not Following link '%s': Max depth reached", url)
 		return ret
 	}
 	// exit condition 2: already visited
-	if !visited.ShouldVisit(url, depth) {
+	if !state.visited.ShouldVisit(url, depth) {
 		log.Info("Already visited '%s'", url)
 		return ret
 	}
+	// exit condition 3: crawler trap
+	if reason := state.traps.Check(url); reason != "" {
+		log.Info("Not scanning '%s': Looks like a crawler trap (%s)", url, reason)
+		return ret
+	}
+	// exit condition 4: budget exhausted
+	if !state.budget.Page(url) {
+		log.Info("Not scanning '%s': Budget exhausted %v", url, state.budget.Hit())
+		return ret
+	}
 
 	log.Info("Scanning url '%s'", url)
-	var links []string
+	var page *backend.Page
 	var err error
-	if links, err = getLinks(cfg, url); err != nil {
-		if strings.Contains(err.Error(), "context canceled") {
-			log.Warn("Failed to get links from url '%s': Context was canceled, retrying...", url)
-			retryAttempts := 1
-			shouldRetry := true
-			success := false
-			for retryAttempts <= cfg.ReconnectAttempts() && shouldRetry {
-				log.Info("Retry attempt %d of %d", retryAttempts, cfg.ReconnectAttempts())
-				reconnect(cfg)
-				if links, err = getLinks(cfg, url); err != nil {
-					shouldRetry = strings.Contains(err.Error(), "context canceled")
-				} else {
-					log.Info("Succeeded at retry attempt %d", retryAttempts)
-					log.Info("Found %d links at url '%s'", len(links), url)
-					shouldRetry = false
-					success = true
-				}
-				retryAttempts++
-			}
-			if !success {
-				log.Error("Failed to get links from url '%s': %s", url, err.Error())
+	page, err = state.backend.GetPage(state.ctx, url)
+	for attempt := 1; err != nil; attempt++ {
+		action := cfg.ErrorPolicy().For(err)
+		if action.Kind != errclass.Retry || attempt > action.Retries || state.ctx.Err() != nil {
+			break
+		}
+		log.Warn("Failed to get links from url '%s': %s, retry attempt %d of %d", url, err.Error(), attempt, action.Retries)
+		// a canceled context means that the browser connection is gone
+		if shouldReconnect(cfg, err) {
+			if err = reconnect(cfg); err != nil {
+				log.Warn("Failed to reconnect: %s", err.Error())
+				continue
 			}
+		}
+		if page, err = state.backend.GetPage(state.ctx, url); err == nil {
+			log.Info("Succeeded at retry attempt %d", attempt)
+		}
+	}
+	if err != nil {
+		if rootCtx.Err() != nil {
+			log.Debug("Crawl interrupted at url '%s': %s", url, err.Error())
+		} else if state.ctx.Err() != nil {
+			log.Debug("Max duration reached at url '%s': %s", url, err.Error())
+		} else if cfg.ErrorPolicy().For(err).Kind == errclass.Ignore {
+			log.Debug("Ignoring error at url '%s': %s", url, err.Error())
 		} else {
 			log.Error("Failed to get links from url '%s': %s", url, err.Error())
 		}
+		if depth == 0 {
+			state.failedSeeds.Add(url)
+		}
+		page = &backend.Page{}
 	} else {
-		log.Info("Found %d links at url '%s'", len(links), url)
+		log.Info("Found %d links at url '%s'", len(page.Links), url)
 	}
+	state.visited.Add(url, depth)
+	// the url after redirects is tracked as well, so that urls redirecting to
+	// the same page are crawled once
+	if len(page.Redirects) > 0 {
+		log.Info("Url '%s' redirected: %s", url, strings.Join(page.Redirects, " -> "))
+		if httpfunc.Downgraded(url, page.Redirects) {
+			log.Warn("Url '%s' redirected from https to http", url)
+		}
+		state.redirects[url] = page.Redirects
+		if final := cfg.Normalizer().Normalize(page.URL); final != url {
+			if !state.visited.ShouldVisit(final, depth) {
+				log.Info("Not following links of '%s': Redirected to already visited url '%s'", url, final)
+				return ret
+			}
+			state.visited.Add(final, depth)
+			if state.budget.Link(final) {
+				ret.Add(final)
+				state.sources.Add(final, state.source(sourceRedirect))
+				state.referrers.Add(final, url)
+			}
+		}
+	}
+	if err == nil && (cfg.Metadata() || cfg.StructuredData()) {
+		visited := output.Page{URL: url, Depth: depth, Data: page.Data}
+		if cfg.Metadata() {
+			visited.Metadata = page.Meta
+		}
+		state.pages[url] = visited
+	}
+	if cfg.Snapshot() != "" && err == nil {
+		state.snapshot.Pages[snapshotKey.Normalize(url)] = page.Text
+	}
+	if state.index != nil && err == nil {
+		state.index.Add(url, page.Text)
+	}
+	if state.records != nil {
+		for _, rec := range page.Records {
+			if err := state.records.Write(rec); err != nil {
+				log.Error("Failed to write record of '%s': %s", url, err.Error())
+			}
+		}
+	}
+	// failed pages and pages without text all have the same empty text
+	if err == nil && strings.TrimSpace(page.Text) != "" {
+		if reason := state.traps.Content(url, page.Text); reason != "" {
+			log.Info("Not following links of '%s': Looks like a crawler trap (%s)", url, reason)
+			return ret
+		}
+	}
+	if cfg.NearDuplicates() {
+		if original, found := state.duplicates.Add(url, page.Text); found {
+			log.Info("Not following links of '%s': Near-duplicate of '%s'", url, original)
+			return ret
+		}
+	}
+	// a page with a canonical url is a duplicate of the canonical page
+	if canonical := cfg.Normalizer().Normalize(page.Canonical); cfg.Canonical() && page.Canonical != "" && canonical != url {
+		if !state.visited.ShouldVisit(canonical, depth) {
+			log.Info("Not following links of '%s': Canonical url '%s' already visited", url, canonical)
+			return ret
+		}
+		log.Debug("Found canonical url '%s' at url '%s'", canonical, url)
+		state.visited.Add(canonical, depth)
+		if state.budget.Link(canonical) {
+			ret.Add(canonical)
+			state.sources.Add(canonical, state.source(sourceCanonical))
+			state.referrers.Add(canonical, url)
+		}
+	}
+	links := filterBudget(normalizeAll(cfg, page.Links), state.budget)
 	ret.Add(links...)
-	visited.Add(url, depth)
+	for _, link := range links {
+		state.sources.Add(link, state.source(sourceDom))
+		state.referrers.Add(link, url)
+	}
+	for _, req := range page.Network {
+		log.Debug("Found network request '%s' (type: %s, status: %d)", req.URL, req.ResourceType, req.Status)
+		link := cfg.Normalizer().Normalize(req.URL)
+		if !state.budget.Link(link) {
+			continue
+		}
+		ret.Add(link)
+		state.sources.Add(link, state.source(networkSource(req)))
+		state.referrers.Add(link, url)
+	}
 
+	nofollow := nofollowLinks(cfg, page.Meta)
+	if nofollow == nil {
+		log.Info("Not following links of '%s': Robots meta tag '%s'", url, page.Meta.Robots)
+		return ret
+	}
 	for _, link := range links {
+		if state.budget.Done() {
+			break
+		}
+		if nofollow[link] {
+			log.Info("Not following link '%s': Link has rel=nofollow", link)
+			continue
+		}
 		if !cfg.FollowInclude().MatchString(link) || cfg.FollowExclude().MatchString(link) {
 			log.Info("Not following link '%s': URL not matching follow-include or matching follow-exclude pattern", link)
 			continue
 		}
-		more := getLinksRecursive(cfg, link, depth+1, visited)
+		if !cfg.Scope().InScope(link) {
+			log.Info("Not following link '%s': URL out of scope '%s'", link, cfg.Scope().Mode())
+			continue
+		}
+		more := getLinksRecursive(cfg, link, depth+1, state)
 		ret.Add(more.Values()...)
 	}
 	return ret
 }
 
-func getLinks(cfg cli.CrawlerConfig, url string) (ret []string, err error) {
-	var resp gson.JSON
+// nofollowLinks returns the normalized links with rel=nofollow, nil if no
+// links of the page may be followed
+func nofollowLinks(cfg cli.CrawlerConfig, m *meta.Metadata) map[string]bool {
+	ret := map[string]bool{}
+	if !cfg.Nofollow() || m == nil {
+		return ret
+	}
+	if m.NoFollow() {
+		return nil
+	}
+	for _, link := range m.Nofollow {
+		ret[cfg.Normalizer().Normalize(link)] = true
+	}
+	return ret
+}
+
+func normalizeAll(cfg cli.CrawlerConfig, links []string) []string {
+	ret := make([]string, len(links))
+	for i, link := range links {
+		ret[i] = cfg.Normalizer().Normalize(link)
+	}
+	return ret
+}
+
+// filterBudget removes the links that exceed the link budget
+func filterBudget(links []string, bdg budget.Budget) []string {
+	ret := []string{}
+	for _, link := range links {
+		if bdg.Link(link) {
+			ret = append(ret, link)
+		}
+	}
+	return ret
+}
+
+// shouldReconnect is true if the browser connection is gone or the browser
+// could not be connected
+func shouldReconnect(cfg cli.CrawlerConfig, err error) bool {
+	if cfg.Renderer() == cli.RendererHTTP {
+		return false
+	}
+	return errclass.Classify(err) == errclass.Canceled || errclass.StepOf(err) == errclass.StepConnect
+}
+
+func getLinks(ctx context.Context, cfg cli.CrawlerConfig, url string, profile *emulation.Profile) (ret *backend.Page, err error) {
+	var res *proto.RuntimeRemoteObject
 	var page *rod.Page
-	ret = []string{}
+	var router *rod.HijackRouter
+	ret = &backend.Page{Links: []string{}}
+	if browser == nil {
+		return ret, errclass.New(errclass.NewStep(errclass.StepOpen, errors.New("browser is not connected")))
+	}
+	state := newHijackState(ctx, cfg, url)
+	if cfg.NetworkLinks() {
+		state.recorder.Start()
+	}
+	state.navigation.Start()
 	defer func() {
-		ex := recover()
-		err = getErr(ex)
+		ret.Network = state.recorder.Stop()
+		ret.Redirects = state.navigation.Stop()
+		ret.URL = url
+		if len(ret.Redirects) > 0 {
+			ret.URL = ret.Redirects[len(ret.Redirects)-1]
+		}
+		if len(state.blocker.Counts()) > 0 {
+			log.Debug("Blocked requests at url '%s': %v", url, state.blocker.Counts())
+		}
+		// errors of the hijacked requests are more specific than the
+		// errors reported by the browser
+		if e2, found := state.errs.TryReceive(); found && err != nil {
+			err = errclass.NewStep(errclass.StepOf(err), e2)
+		}
+		err = errclass.New(err)
 		if err != nil {
-			log.Debug("Cached error at getLinks: %s", err.Error())
+			log.Debug("Error at getLinks: %s", err.Error())
+		}
+		if router != nil {
+			if e2 := router.Stop(); e2 != nil {
+				log.Debug("Failed to stop hijack router: %s", e2.Error())
+			}
 		}
 		if page != nil {
 			log.Debug("Closing page")
-			if e2 := page.Close(); e2 != nil {
+			if e2 := page.Context(context.Background()).Close(); e2 != nil {
 				log.Debug("Failed to close page: %s", e2.Error())
 			}
 		}
@@ -174,83 +969,471 @@ func getLinks(cfg cli.CrawlerConfig, url string) (ret []string, err error) {
 
 	// Navigate and load
 	log.Debug("Opening page")
-	page = browser.MustPage("")
+	if page, err = browser.Context(ctx).Page(proto.TargetCreateTarget{}); err != nil {
+		return ret, errclass.NewStep(errclass.StepOpen, err)
+	}
+	if router, err = hijack(page, state); err != nil {
+		return ret, errclass.NewStep(errclass.StepOpen, err)
+	}
+	if profile != nil {
+		log.Debug("Emulating profile '%s'", profile.Name)
+		if err = page.Emulate(profile.Device); err != nil {
+			return ret, errclass.NewStep(errclass.StepEmulate, err)
+		}
+	}
 	log.Debug("Navigating")
-	page.Timeout(cfg.Timeout()).MustNavigate(url).MustWaitLoad()
+	if err = step(ctx, page, errclass.StepNavigate, cfg.Timeout(), func(p *rod.Page) error {
+		return p.Navigate(url)
+	}); err != nil {
+		return
+	}
+	if err = step(ctx, page, errclass.StepLoad, cfg.Timeout(), func(p *rod.Page) error {
+		return p.WaitLoad()
+	}); err != nil {
+		return
+	}
 
 	// Wait additional time
 	if cfg.ExtraWaittime() != 0 {
 		log.Debug("Waiting for additional waittime")
-		page.MustEvaluate(js.CreateWaitFunc(cfg.ExtraWaittime()))
+		if err = step(ctx, page, errclass.StepWait, cfg.ExtraWaittime()+cfg.Timeout(), func(p *rod.Page) error {
+			_, e2 := p.Evaluate(js.CreateWaitFunc(cfg.ExtraWaittime()))
+			return e2
+		}); err != nil {
+			return
+		}
+	}
+
+	// Requests may have aborted the page
+	if e2, found := state.abort.TryReceive(); found {
+		return ret, errclass.NewStep(errclass.StepLoad, e2)
+	}
+
+	// Cookies set by scripts are merged into the session
+	if e2 := sess.Capture(browser); e2 != nil {
+		log.Debug("Failed to capture browser cookies: %s", e2.Error())
 	}
 
 	// Get links
 	log.Debug("Running getLinks JS func")
-	resp = page.MustEval(js.GetLinks)
+	if err = step(ctx, page, errclass.StepEval, cfg.Timeout(), func(p *rod.Page) error {
+		var e2 error
+		res, e2 = p.Eval(js.GetLinks)
+		return e2
+	}); err != nil {
+		return
+	}
 	log.Debug("Parsing JSON")
-	for _, link := range resp.Arr() {
-		ret = append(ret, link.String())
+	for _, link := range res.Value.Arr() {
+		ret.Links = append(ret.Links, link.String())
+	}
+	if cfg.Canonical() {
+		if err = step(ctx, page, errclass.StepEval, cfg.Timeout(), func(p *rod.Page) error {
+			var e2 error
+			res, e2 = p.Eval(js.GetCanonical)
+			return e2
+		}); err != nil {
+			return
+		}
+		ret.Canonical = res.Value.String()
+	}
+	if cfg.Metadata() || cfg.Nofollow() {
+		if err = step(ctx, page, errclass.StepEval, cfg.Timeout(), func(p *rod.Page) error {
+			var e2 error
+			res, e2 = p.Eval(js.GetMetadata)
+			return e2
+		}); err != nil {
+			return
+		}
+		ret.Meta = meta.New()
+		if err = json.Unmarshal([]byte(res.Value.JSON("", "")), ret.Meta); err != nil {
+			return ret, errclass.NewStep(errclass.StepEval, err)
+		}
+	}
+	if needText(cfg) {
+		if err = step(ctx, page, errclass.StepEval, cfg.Timeout(), func(p *rod.Page) error {
+			var e2 error
+			res, e2 = p.Eval(js.GetText)
+			return e2
+		}); err != nil {
+			return
+		}
+		ret.Text = res.Value.String()
+	}
+	if cfg.StructuredData() {
+		if err = step(ctx, page, errclass.StepEval, cfg.Timeout(), func(p *rod.Page) error {
+			var e2 error
+			res, e2 = p.Eval(js.GetStructuredData)
+			return e2
+		}); err != nil {
+			return
+		}
+		raw := structured.Raw{}
+		if err = json.Unmarshal([]byte(res.Value.JSON("", "")), &raw); err != nil {
+			return ret, errclass.NewStep(errclass.StepEval, err)
+		}
+		var invalid int
+		if ret.Data, invalid = structured.Parse(raw); invalid > 0 {
+			log.Debug("Skipped %d invalid json-ld blocks", invalid)
+		}
+	}
+	for _, schema := range extract.Match(cfg.Schemas(), url) {
+		log.Debug("Extracting schema '%s'", schema.Name)
+		if err = step(ctx, page, errclass.StepEval, cfg.Timeout(), func(p *rod.Page) error {
+			var e2 error
+			res, e2 = p.Eval(js.Extract, schema.Fields)
+			return e2
+		}); err != nil {
+			return
+		}
+		values := map[string][]string{}
+		if err = json.Unmarshal([]byte(res.Value.JSON("", "")), &values); err != nil {
+			return ret, errclass.NewStep(errclass.StepEval, err)
+		}
+		ret.Records = append(ret.Records, schema.Record(url, values))
 	}
 	return
 }
 
-func reconnect(cfg cli.CrawlerConfig) {
+// needText is true if the text of the pages is used
+func needText(cfg cli.CrawlerConfig) bool {
+	return cfg.TrapLimits().Content || cfg.NearDuplicates() || cfg.Index() != "" || cfg.Snapshot() != ""
+}
+
+// step runs a single step of loading a page with its own deadline
+func step(ctx context.Context, page *rod.Page, name string, timeout time.Duration, fn func(p *rod.Page) error) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return errclass.NewStep(name, fn(page.Context(ctx)))
+}
+
+func exportCookies(cfg cli.CrawlerConfig) {
+	if browser != nil {
+		if err := sess.Capture(browser); err != nil {
+			log.Warn("Failed to capture browser cookies: %s", err.Error())
+		}
+	}
+	if err := sess.Export(cfg.CookiesExport()); err != nil {
+		log.Error("Failed to export cookies to '%s': %s", cfg.CookiesExport(), err.Error())
+		return
+	}
+	log.Info("Exported %d cookies to '%s'", sess.Len(), cfg.CookiesExport())
+}
+
+func login(cfg cli.CrawlerConfig) error {
+	page, err := browser.Page(proto.TargetCreateTarget{})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if e2 := page.Close(); e2 != nil {
+			log.Debug("Failed to close login page: %s", e2.Error())
+		}
+	}()
+	router, err := hijack(page, newHijackState(rootCtx, cfg, cfg.LoginUrl()))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if e2 := router.Stop(); e2 != nil {
+			log.Debug("Failed to stop hijack router: %s", e2.Error())
+		}
+	}()
+	if err := session.Login(page, cfg, log); err != nil {
+		return err
+	}
+	if err := sess.Capture(browser); err != nil {
+		return err
+	}
+	log.Info("Captured %d session cookies", sess.Len())
+	return nil
+}
+
+func reconnect(cfg cli.CrawlerConfig) error {
+	var err error
+	var u string
 	disconnect()
 	log.Debug("Opening Browser")
-	browser = rod.New().MustConnect()
+	if u, err = launch(cfg); err != nil {
+		return errclass.NewStep(errclass.StepConnect, err)
+	}
+	b := rod.New().ControlURL(u)
+	if err = b.Connect(); err != nil {
+		return errclass.NewStep(errclass.StepConnect, err)
+	}
+	browser = b
+	browserKey = cli.LaunchKey(cfg)
+	remote = cfg.RemoteBrowser() != ""
+	if err = sess.Apply(browser); err != nil {
+		log.Warn("Failed to apply session cookies: %s", err.Error())
+	}
+	useConfig(cfg)
+	return nil
+}
+
+// hijackState is the state of the hijacked requests of a single page, it is
+// captured by the router of the page so that late requests of a page are
+// never attributed to the next one
+type hijackState struct {
+	ctx        context.Context
+	cfg        cli.CrawlerConfig
+	url        string
+	blocker    block.Blocker
+	recorder   types.RequestRecorder
+	navigation types.NavigationRecorder
+	// errs has the error of the navigation request, abort the error of a
+	// request that aborted the page
+	errs  types.ErrorSwitchChannel
+	abort types.ErrorSwitchChannel
+}
+
+func newHijackState(ctx context.Context, cfg cli.CrawlerConfig, url string) *hijackState {
+	return &hijackState{
+		ctx:        ctx,
+		cfg:        cfg,
+		url:        url,
+		blocker:    blocker.Copy(),
+		recorder:   types.NewRequestRecorder(),
+		navigation: types.NewNavigationRecorder(),
+		errs:       types.NewErrorSwitchChannel(),
+		abort:      types.NewErrorSwitchChannel(),
+	}
+}
+
+// mainFrame is true for the navigation of the page itself, iframes are
+// documents as well but handled like the other sub-requests of the page
+func (state *hijackState) mainFrame(ctx *rod.Hijack) bool {
+	return ctx.Request.IsNavigation() && frameKey.Normalize(ctx.Request.URL().String()) == frameKey.Normalize(state.url)
+}
+
+// hijack loads all requests of the page through the session with the headers,
+// blocking and error policy of the crawl, the router must be stopped before
+// the page is closed
+func hijack(page *rod.Page, state *hijackState) (*rod.HijackRouter, error) {
 	log.Debug("Adding Hijack Router")
-	router = browser.HijackRequests()
-	router.MustAdd("*/*", func(ctx *rod.Hijack) {
-		for k, v := range cfg.Headers() {
-			ctx.Request.Req().Header.Set(k, v)
+	router := page.HijackRequests()
+	err := router.Add("*/*", "", func(ctx *rod.Hijack) {
+		if state.blocker.Block(string(ctx.Request.Type()), ctx.Request.URL()) {
+			ctx.Response.Fail(proto.NetworkErrorReasonBlockedByClient)
+			return
 		}
-		success := false
-		for !success {
-			if err := ctx.LoadResponse(http.DefaultClient, true); err != nil {
-				if !checkConnectError(err) {
-					log.Error("Failed to load response: %s, retrying in 1s...", err.Error())
-					time.Sleep(1 * time.Second)
-				} else {
-					log.Debug("Ignoring Connect error: %s", err.Error())
-					success = true
-				}
-			} else {
-				success = true
+		for k, v := range state.cfg.Headers() {
+			// the user agent is set by the emulated profile
+			if k == "user-agent" && len(state.cfg.Profiles()) > 0 {
+				continue
 			}
+			ctx.Request.Req().Header.Set(k, v)
 		}
+		// the session jar is the single source of cookies
+		ctx.Request.Req().Header.Del("cookie")
+		handleRequest(state, ctx)
 	})
+	if err != nil {
+		return nil, err
+	}
 	go router.Run()
+	return router, nil
 }
 
-func disconnect() {
-	if router != nil {
-		router.Stop()
+// handleRequest loads the response of a hijacked request, a failed navigation
+// fails once and the error policy is applied by the page loop, failed
+// sub-requests apply the sub-request policy
+func handleRequest(state *hijackState, ctx *rod.Hijack) {
+	u := ctx.Request.URL().String()
+	for attempt := 1; ; attempt++ {
+		err := loadResponse(state, ctx)
+		if err == nil && ctx.Response.Payload().ResponseCode >= 400 {
+			err = errclass.NewStatus(ctx.Response.Payload().ResponseCode)
+		}
+		if err == nil {
+			recordRequest(state, ctx)
+			return
+		}
+		err = errclass.New(err)
+		if state.mainFrame(ctx) {
+			// the navigation decides over the page, it is retried by the
+			// page loop
+			log.Debug("Failed to load response for url '%s': %s", u, err.Error())
+			state.errs.Send(err)
+		} else if action := state.cfg.SubrequestPolicy().For(err); action.Kind == errclass.Retry && attempt <= action.Retries {
+			log.Warn("Failed to load response for url '%s': %s, retry attempt %d of %d in 1s...", u, err.Error(), attempt, action.Retries)
+			select {
+			case <-state.ctx.Done():
+				ctx.Response.Fail(proto.NetworkErrorReasonAborted)
+				return
+			case <-time.After(1 * time.Second):
+			}
+			resetRequest(ctx)
+			continue
+		} else if action.Kind == errclass.Abort {
+			log.Error("Failed to load response for url '%s': %s, aborting page", u, err.Error())
+			state.abort.Send(err)
+		} else {
+			log.Debug("Failed to load response for url '%s': %s", u, err.Error())
+		}
+		// responses with error status are passed to the browser as they are
+		if errclass.Classify(err) == errclass.Status {
+			recordRequest(state, ctx)
+			return
+		}
+		ctx.Response.Fail(failReason(err))
+		return
 	}
-	if browser != nil {
-		if err := browser.Close(); err != nil {
-			log.Debug("Failed to close browser: %s", err.Error())
+}
+
+func failReason(err error) proto.NetworkErrorReason {
+	switch errclass.Classify(err) {
+	case errclass.DNS:
+		return proto.NetworkErrorReasonNameNotResolved
+	case errclass.Timeout:
+		return proto.NetworkErrorReasonTimedOut
+	case errclass.Refused:
+		return proto.NetworkErrorReasonConnectionRefused
+	case errclass.Canceled:
+		return proto.NetworkErrorReasonAborted
+	default:
+		return proto.NetworkErrorReasonFailed
+	}
+}
+
+func resetRequest(ctx *rod.Hijack) {
+	ctx.Request.Req().Body = ioutil.NopCloser(strings.NewReader(ctx.Request.Body()))
+	ctx.Response.Payload().ResponseHeaders = nil
+	ctx.Response.Payload().Body = nil
+}
+
+func loadResponse(state *hijackState, ctx *rod.Hijack) (err error) {
+	cfg := state.cfg
+	// redirects are followed by the client, the browser only sees the final
+	// response
+	redirects := httpfunc.NewRedirects(cfg.MaxRedirects())
+	defer func() {
+		if err == nil && state.mainFrame(ctx) {
+			state.navigation.Record(redirects.URLs)
 		}
+	}()
+	if err := cli.Authorize(cfg, ctx.Request.Req()); err != nil {
+		return err
+	}
+	if err := ctx.LoadResponse(redirects.Client(sess.Client()), true); err != nil {
+		return err
 	}
+	if ctx.Response.Payload().ResponseCode != http.StatusUnauthorized || cfg.Credentials() == nil {
+		return nil
+	}
+	// token may have been revoked before it expired, retry once with a new one
+	log.Debug("Received status 401 for url '%s', refreshing token", ctx.Request.URL().String())
+	cfg.Credentials().Invalidate()
+	if err := cli.Authorize(cfg, ctx.Request.Req()); err != nil {
+		return err
+	}
+	resetRequest(ctx)
+	redirects.URLs = []string{}
+	return ctx.LoadResponse(redirects.Client(sess.Client()), true)
 }
 
-func checkConnectError(err error) bool {
-	for _, e := range validConnectErrs {
-		strings.Contains(err.Error(), e)
-		return true
+func recordRequest(state *hijackState, ctx *rod.Hijack) {
+	status := ctx.Response.Payload().ResponseCode
+	resourceType := string(ctx.Request.Type())
+	state.recorder.Record(types.NetworkRequest{
+		URL:          ctx.Request.URL().String(),
+		ResourceType: resourceType,
+		Status:       status,
+	})
+	// urls may also be hidden inside of api responses
+	if !state.cfg.NetworkLinks() || !strings.Contains(ctx.Response.Headers().Get("content-type"), "json") {
+		return
+	}
+	for _, link := range jsonLinks(ctx.Response.Body()) {
+		state.recorder.Record(types.NetworkRequest{
+			URL:          link,
+			ResourceType: "JSON",
+			Status:       status,
+		})
 	}
-	return false
 }
 
-func getErr(ex interface{}) error {
-	if ex != nil {
-		switch x := ex.(type) {
-		case string:
-			return errors.New(x)
-		case error:
-			return x
-		default:
-			return errors.New(fmt.Sprintf("%v", x))
+// jsonLinks returns the urls in the string values of a json body, escaped
+// slashes are unescaped
+func jsonLinks(body string) []string {
+	return findJSONUrls.FindAllString(strings.ReplaceAll(body, `\/`, "/"), -1)
+}
+
+// networkSource is the source marker of a network request
+func networkSource(req types.NetworkRequest) string {
+	return fmt.Sprintf("%s:%s:%d", sourceNetwork, req.ResourceType, req.Status)
+}
+
+func launch(cfg cli.CrawlerConfig) (string, error) {
+	if cfg.RemoteBrowser() != "" {
+		log.Debug("Connecting to remote browser '%s'", cfg.RemoteBrowser())
+		return launcher.ResolveURL(cfg.RemoteBrowser())
+	}
+	l := launcher.New().Headless(!cfg.Headful())
+	if cfg.BrowserBin() != "" {
+		l = l.Bin(cfg.BrowserBin())
+	}
+	if cfg.UserDataDir() != "" {
+		l = l.UserDataDir(cfg.UserDataDir())
+	}
+	for name, val := range cfg.BrowserFlags() {
+		if val == "" {
+			l = l.Set(flags.Flag(name))
+		} else {
+			l = l.Set(flags.Flag(name), val)
 		}
 	}
+	// requests are hijacked and loaded through the proxy pool, the browser
+	// proxy only applies to traffic that can't be hijacked (i.e. websockets)
+	if len(cfg.Proxies()) == 1 {
+		u := cfg.Proxies()[0]
+		log.Debug("Launching browser with proxy '%s'", u.Redacted())
+		l = l.Proxy(u.Scheme + "://" + u.Host)
+	}
+	return l.Launch()
+}
+
+// connect reuses the connected browser for the crawl or reconnects if there
+// is none, it was launched with other options or it does not respond
+func connect(cfg cli.CrawlerConfig) error {
+	if browser == nil {
+		return reconnect(cfg)
+	}
+	// i.e. a job of the daemon with another proxy or browser binary
+	if cli.LaunchKey(cfg) != browserKey {
+		log.Info("Browser options changed, relaunching browser")
+		return reconnect(cfg)
+	}
+	if _, err := (proto.BrowserGetVersion{}).Call(browser.Timeout(5 * time.Second)); err != nil {
+		log.Warn("Browser does not respond, reconnecting: %s", err.Error())
+		return reconnect(cfg)
+	}
+	log.Debug("Reusing browser")
+	useConfig(cfg)
+	// cookies of the previous crawl are replaced by the session
+	if err := browser.SetCookies(nil); err != nil {
+		log.Warn("Failed to clear browser cookies: %s", err.Error())
+	}
+	if err := sess.Apply(browser); err != nil {
+		log.Warn("Failed to apply session cookies: %s", err.Error())
+	}
 	return nil
 }
+
+// useConfig applies the blocking rules of the crawl to the hijacked requests
+func useConfig(cfg cli.CrawlerConfig) {
+	blocker = block.New(cfg.BlockTypes(), cfg.Block(), cfg.BlockDomains())
+}
+
+func disconnect() {
+	// the remote browser is not owned by the crawler and stays open
+	if browser != nil && remote {
+		log.Debug("Leaving remote browser open")
+	} else if browser != nil {
+		// the browser is closed even if the crawl was interrupted
+		if err := browser.Context(context.Background()).Close(); err != nil {
+			log.Debug("Failed to close browser: %s", err.Error())
+		}
+	}
+	browser = nil
+}
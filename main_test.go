@@ -5,6 +5,7 @@ import (
 	"flag"
 	"net/http"
 	"os"
+	"reflect"
 	"strconv"
 	"testing"
 	"time"
@@ -12,6 +13,7 @@ import (
 	"github.com/go-rod/rod"
 	"github.com/markoczy/crawler/cli"
 	"github.com/markoczy/crawler/logger"
+	"github.com/markoczy/crawler/types"
 )
 
 func TestMain(m *testing.M) {
@@ -150,7 +152,7 @@ func testGetLinks(t *testing.T, depth int, timeout time.Duration, expected []str
 	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
 	cfg := cli.ParseFlags()
 
-	links := getAllLinks(cfg)
+	links := getAllLinks(cfg, newCrawlState(cfg))
 	for _, link := range links.Values() {
 		log.Info("Link:", link)
 	}
@@ -171,3 +173,48 @@ func testGetLinks(t *testing.T, depth int, timeout time.Duration, expected []str
 		t.Fail()
 	}
 }
+
+func TestJSONLinks(t *testing.T) {
+	body := `{"next": "https:\/\/api.example.com\/items?page=2", "image": {"src": "http://cdn.example.com/a.png"}, "text": "no url", "id": 3}`
+	expected := []string{"https://api.example.com/items?page=2", "http://cdn.example.com/a.png"}
+	if links := jsonLinks(body); !reflect.DeepEqual(links, expected) {
+		t.Errorf("Expected links %v but found %v", expected, links)
+	}
+	if links := jsonLinks(`{"a": 1}`); len(links) != 0 {
+		t.Errorf("Expected no links but found %v", links)
+	}
+}
+
+func TestNetworkSource(t *testing.T) {
+	tests := map[string]types.NetworkRequest{
+		"network:XHR:200":   {URL: "http://x/api", ResourceType: "XHR", Status: 200},
+		"network:Image:404": {URL: "http://x/a.png", ResourceType: "Image", Status: 404},
+		"network:JSON:200":  {URL: "http://x/b", ResourceType: "JSON", Status: 200},
+	}
+	for expected, req := range tests {
+		if source := networkSource(req); source != expected {
+			t.Errorf("Expected source '%s' but found '%s'", expected, source)
+		}
+	}
+}
+
+func TestMatchSources(t *testing.T) {
+	tests := []struct {
+		args     []string
+		sources  []string
+		expected bool
+	}{
+		{nil, []string{"dom"}, true},
+		{[]string{"-source-include", "^network:"}, []string{"dom"}, false},
+		{[]string{"-source-include", "^network:"}, []string{"dom", "network:XHR:200"}, true},
+		{[]string{"-source-exclude", ":(4|5)\\d\\d$"}, []string{"network:Image:404"}, false},
+		{[]string{"-source-exclude", ":(4|5)\\d\\d$"}, []string{"network:Image:404", "dom"}, true},
+		{[]string{"-source-include", "^network:XHR", "-source-exclude", ":404$"}, []string{"network:XHR:404"}, false},
+	}
+	for _, test := range tests {
+		cfg := cli.ParseArgs(flag.NewFlagSet("sources", flag.ExitOnError), append(test.args, "-url", "http://localhost/"))
+		if match := matchSources(cfg, test.sources); match != test.expected {
+			t.Errorf("Expected match %v of sources %v with args %v", test.expected, test.sources, test.args)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package meta
+
+import (
+	"strings"
+)
+
+// Metadata is the seo metadata of a page
+type Metadata struct {
+	Title       string            `json:"title"`
+	Description string            `json:"description,omitempty"`
+	Robots      string            `json:"robots,omitempty"`
+	Canonical   string            `json:"canonical,omitempty"`
+	Hreflang    map[string]string `json:"hreflang,omitempty"`
+	OpenGraph   map[string]string `json:"og,omitempty"`
+	H1          []string          `json:"h1,omitempty"`
+	H2          []string          `json:"h2,omitempty"`
+	H3          []string          `json:"h3,omitempty"`
+	WordCount   int               `json:"wordCount"`
+	// Nofollow are the links with rel=nofollow
+	Nofollow []string `json:"nofollow,omitempty"`
+}
+
+func New() *Metadata {
+	return &Metadata{
+		Hreflang:  map[string]string{},
+		OpenGraph: map[string]string{},
+		H1:        []string{},
+		H2:        []string{},
+		H3:        []string{},
+		Nofollow:  []string{},
+	}
+}
+
+// NoFollow is true if the robots meta tag forbids following the links
+func (m *Metadata) NoFollow() bool {
+	for _, directive := range strings.Split(strings.ToLower(m.Robots), ",") {
+		directive = strings.TrimSpace(directive)
+		if directive == "nofollow" || directive == "none" {
+			return true
+		}
+	}
+	return false
+}
+
+// WordCount counts the words of the text
+func WordCount(text string) int {
+	return len(strings.Fields(text))
+}
@@ -0,0 +1,209 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/markoczy/crawler/meta"
+	"github.com/markoczy/crawler/structured"
+)
+
+const (
+	FormatText  = "text"
+	FormatJSONL = "jsonl"
+)
+
+// types of the jsonl records, each record has the type in field 'type'
+const (
+	TypeLink    = "link"
+	TypePage    = "page"
+	TypeTrap    = "trap"
+	TypeCluster = "duplicates"
+	TypeCheck   = "check"
+	TypeChange  = "change"
+)
+
+// Link is a found link together with the sources it was found by
+type Link struct {
+	URL     string   `json:"url"`
+	Sources []string `json:"sources,omitempty"`
+	// Redirects of the link if it was visited
+	Redirects []string `json:"redirects,omitempty"`
+}
+
+// Page is a visited page with the data extracted from it
+type Page struct {
+	URL      string         `json:"url"`
+	Depth    int            `json:"depth"`
+	Metadata *meta.Metadata `json:"metadata,omitempty"`
+	// Data is the structured data of the page
+	Data []structured.Item `json:"structuredData,omitempty"`
+}
+
+// Trap is an url that was not crawled because it looks like a crawler trap
+type Trap struct {
+	URL    string `json:"url"`
+	Reason string `json:"trap"`
+}
+
+// Cluster is a group of near-duplicate pages, the first url is the page the
+// others are duplicates of
+type Cluster struct {
+	URLs []string `json:"duplicates"`
+}
+
+// Change is a difference to the previous crawl, the diff of changed pages is
+// a unified diff of the text
+type Change struct {
+	URL    string `json:"url"`
+	Change string `json:"change"`
+	Diff   string `json:"diff,omitempty"`
+}
+
+// CheckedLink is the status of a link, links are broken if they could not
+// be loaded or responded with an error status
+type CheckedLink struct {
+	URL       string   `json:"url"`
+	Status    int      `json:"status,omitempty"`
+	Redirects []string `json:"redirects,omitempty"`
+	Error     string   `json:"error,omitempty"`
+	Broken    bool     `json:"broken"`
+}
+
+// PageCheck is the status of all links found on a page, the page of seed
+// urls is empty
+type PageCheck struct {
+	Page  string        `json:"page"`
+	Links []CheckedLink `json:"links"`
+}
+
+// Writer writes the results of a crawl in the selected format
+type Writer interface {
+	WriteLink(link Link) error
+	WritePage(page Page) error
+	WriteTrap(trap Trap) error
+	WriteCluster(cluster Cluster) error
+	WriteCheck(check PageCheck) error
+	WriteChange(change Change) error
+}
+
+func New(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatText:
+		return &textWriter{w: w}, nil
+	case FormatJSONL:
+		return &jsonlWriter{enc: json.NewEncoder(w)}, nil
+	default:
+		return nil, fmt.Errorf("Unknown output format '%s', expected '%s' or '%s'", format, FormatText, FormatJSONL)
+	}
+}
+
+type textWriter struct {
+	w io.Writer
+}
+
+func (tw *textWriter) WriteLink(link Link) error {
+	_, err := fmt.Fprintln(tw.w, link.URL)
+	return err
+}
+
+func (tw *textWriter) WritePage(page Page) error {
+	if page.Metadata == nil {
+		_, err := fmt.Fprintf(tw.w, "%s\t%d\n", page.URL, page.Depth)
+		return err
+	}
+	_, err := fmt.Fprintf(tw.w, "%s\t%d\t%d\t%s\n", page.URL, page.Depth, page.Metadata.WordCount, page.Metadata.Title)
+	return err
+}
+
+func (tw *textWriter) WriteTrap(trap Trap) error {
+	_, err := fmt.Fprintf(tw.w, "%s\t%s\n", trap.URL, trap.Reason)
+	return err
+}
+
+func (tw *textWriter) WriteCluster(cluster Cluster) error {
+	_, err := fmt.Fprintln(tw.w, strings.Join(cluster.URLs, "\t"))
+	return err
+}
+
+func (tw *textWriter) WriteCheck(check PageCheck) error {
+	page := check.Page
+	if page == "" {
+		page = "(seed)"
+	}
+	if _, err := fmt.Fprintln(tw.w, page); err != nil {
+		return err
+	}
+	for _, link := range check.Links {
+		state := "OK"
+		if link.Broken {
+			state = "BROKEN"
+		}
+		line := fmt.Sprintf("\t%s %d %s", state, link.Status, link.URL)
+		for _, redirect := range link.Redirects {
+			line += " -> " + redirect
+		}
+		if link.Error != "" {
+			line += " (" + link.Error + ")"
+		}
+		if _, err := fmt.Fprintln(tw.w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (tw *textWriter) WriteChange(change Change) error {
+	_, err := fmt.Fprintf(tw.w, "%s\t%s\n%s", change.Change, change.URL, change.Diff)
+	return err
+}
+
+type jsonlWriter struct {
+	enc *json.Encoder
+}
+
+// the fields of the embedded records are inlined next to the type
+
+func (jw *jsonlWriter) WriteLink(link Link) error {
+	return jw.enc.Encode(struct {
+		Type string `json:"type"`
+		Link
+	}{TypeLink, link})
+}
+
+func (jw *jsonlWriter) WritePage(page Page) error {
+	return jw.enc.Encode(struct {
+		Type string `json:"type"`
+		Page
+	}{TypePage, page})
+}
+
+func (jw *jsonlWriter) WriteTrap(trap Trap) error {
+	return jw.enc.Encode(struct {
+		Type string `json:"type"`
+		Trap
+	}{TypeTrap, trap})
+}
+
+func (jw *jsonlWriter) WriteCluster(cluster Cluster) error {
+	return jw.enc.Encode(struct {
+		Type string `json:"type"`
+		Cluster
+	}{TypeCluster, cluster})
+}
+
+func (jw *jsonlWriter) WriteCheck(check PageCheck) error {
+	return jw.enc.Encode(struct {
+		Type string `json:"type"`
+		PageCheck
+	}{TypeCheck, check})
+}
+
+func (jw *jsonlWriter) WriteChange(change Change) error {
+	return jw.enc.Encode(struct {
+		Type string `json:"type"`
+		Change
+	}{TypeChange, change})
+}
@@ -0,0 +1,21 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestJSONLType(t *testing.T) {
+	buf := &bytes.Buffer{}
+	out, _ := New(FormatJSONL, buf)
+	out.WriteLink(Link{URL: "http://x/a", Sources: []string{"dom"}})
+	out.WritePage(Page{URL: "http://x/", Depth: 0})
+	out.WriteTrap(Trap{URL: "http://x/cal", Reason: "repeated-segments"})
+	expected := `{"type":"link","url":"http://x/a","sources":["dom"]}
+{"type":"page","url":"http://x/","depth":0}
+{"type":"trap","url":"http://x/cal","trap":"repeated-segments"}
+`
+	if buf.String() != expected {
+		t.Errorf("Expected records\n%s\nbut found\n%s", expected, buf.String())
+	}
+}
@@ -0,0 +1,48 @@
+package proxy
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"strings"
+)
+
+// Parse parses a proxy url, supported schemes are http, https and socks5
+func Parse(raw string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("Unsupported proxy scheme '%s' in '%s', expected http, https or socks5", u.Scheme, u.Redacted())
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("Missing host in proxy '%s'", u.Redacted())
+	}
+	return u, nil
+}
+
+// ParseFile parses a proxy pool file with one proxy url per line, empty lines
+// and lines starting with '#' are ignored
+func ParseFile(path string) ([]*url.URL, error) {
+	var err error
+	var dat []byte
+	if dat, err = ioutil.ReadFile(path); err != nil {
+		return nil, err
+	}
+	ret := []*url.URL{}
+	for _, line := range strings.Split(string(dat), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var u *url.URL
+		if u, err = Parse(line); err != nil {
+			return nil, err
+		}
+		ret = append(ret, u)
+	}
+	return ret, nil
+}
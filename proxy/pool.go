@@ -0,0 +1,185 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/markoczy/crawler/logger"
+)
+
+const (
+	RotateRoundRobin = "round-robin"
+	RotateHost       = "host"
+)
+
+// Pool is a http.RoundTripper that sends each request through one of its
+// proxies, proxies that fail too often in a row are taken out of the pool
+type Pool interface {
+	http.RoundTripper
+	Healthy() int
+}
+
+type entry struct {
+	url       *url.URL
+	transport *http.Transport
+	failures  int
+	healthy   bool
+}
+
+type pool struct {
+	entries     []*entry
+	rotation    string
+	maxFailures int
+	next        int
+	log         logger.Logger
+	mux         sync.Mutex
+}
+
+func New(proxies []*url.URL, rotation string, maxFailures int, log logger.Logger) (Pool, error) {
+	if len(proxies) == 0 {
+		return nil, errors.New("Proxy pool must not be empty")
+	}
+	if rotation != RotateRoundRobin && rotation != RotateHost {
+		return nil, fmt.Errorf("Unknown proxy rotation '%s', expected '%s' or '%s'", rotation, RotateRoundRobin, RotateHost)
+	}
+	entries := []*entry{}
+	for _, u := range proxies {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.Proxy = http.ProxyURL(u)
+		entries = append(entries, &entry{
+			url:       u,
+			transport: transport,
+			healthy:   true,
+		})
+	}
+	return &pool{
+		entries:     entries,
+		rotation:    rotation,
+		maxFailures: maxFailures,
+		log:         log,
+		mux:         sync.Mutex{},
+	}, nil
+}
+
+func (p *pool) RoundTrip(req *http.Request) (*http.Response, error) {
+	var e *entry
+	var err error
+	if e, err = p.pick(req.URL.Hostname()); err != nil {
+		return nil, err
+	}
+	resp, err := e.transport.RoundTrip(req)
+	// errors of the target (i.e. refused connections or dns errors reported
+	// by the proxy) say nothing about the health of the proxy
+	if err != nil && proxyFailure(err) {
+		p.failed(e, err.Error())
+	} else if err == nil && resp.StatusCode == http.StatusProxyAuthRequired {
+		p.failed(e, resp.Status)
+	} else if err == nil {
+		p.succeeded(e)
+	}
+	return resp, err
+}
+
+// proxyFailure is true if the proxy itself failed: it could not be connected,
+// rejected the CONNECT request or the socks authentication
+func proxyFailure(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		// the transport wraps all errors of connecting to the proxy
+		if opErr.Op == "proxyconnect" {
+			return true
+		}
+		// socks errors are reported with the proxy as source
+		if opErr.Source != nil && opErr.Err != nil {
+			msg := opErr.Err.Error()
+			return strings.Contains(msg, "authentication") || strings.Contains(msg, "username/password")
+		}
+		return false
+	}
+	return connectStatus(err)
+}
+
+// connectStatus is true if the error is a non 200 status of a CONNECT request
+// except the gateway errors, which the proxy reports if the target is not
+// reachable. The transport reports these as plain errors with the status
+// text (before go 1.20 prefixed by the status code)
+func connectStatus(err error) bool {
+	for unwrapped := errors.Unwrap(err); unwrapped != nil; unwrapped = errors.Unwrap(err) {
+		err = unwrapped
+	}
+	text := err.Error()
+	if i := strings.Index(text, " "); i > 0 {
+		if _, e2 := strconv.Atoi(text[:i]); e2 == nil {
+			text = text[i+1:]
+		}
+	}
+	for code := 400; code < 600; code++ {
+		if code == http.StatusBadGateway || code == http.StatusGatewayTimeout {
+			continue
+		}
+		if status := http.StatusText(code); status != "" && status == text {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *pool) Healthy() int {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	return len(p.healthy())
+}
+
+func (p *pool) pick(host string) (*entry, error) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	healthy := p.healthy()
+	if len(healthy) == 0 {
+		return nil, errors.New("No healthy proxy left in pool")
+	}
+	if p.rotation == RotateHost {
+		h := fnv.New32a()
+		h.Write([]byte(strings.ToLower(host)))
+		return healthy[int(h.Sum32()%uint32(len(healthy)))], nil
+	}
+	p.next = (p.next + 1) % len(healthy)
+	return healthy[p.next], nil
+}
+
+func (p *pool) healthy() []*entry {
+	ret := []*entry{}
+	for _, e := range p.entries {
+		if e.healthy {
+			ret = append(ret, e)
+		}
+	}
+	return ret
+}
+
+func (p *pool) failed(e *entry, reason string) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	e.failures++
+	p.log.Debug("Request through proxy '%s' failed (%d of %d): %s", e.url.Redacted(), e.failures, p.maxFailures, reason)
+	if e.healthy && e.failures >= p.maxFailures {
+		e.healthy = false
+		p.log.Warn("Removing unhealthy proxy '%s' from pool", e.url.Redacted())
+	}
+}
+
+func (p *pool) succeeded(e *entry) {
+	p.mux.Lock()
+	e.failures = 0
+	p.mux.Unlock()
+}
@@ -0,0 +1,162 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxLookahead bounds the search for the next run of schedules that never
+// match (i.e. the 31st of february)
+const maxLookahead = 5 * 366 * 24 * time.Hour
+
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Schedule is a cron schedule
+type Schedule interface {
+	// Next returns the first minute after t that matches the schedule, the
+	// zero time if there is none
+	Next(t time.Time) time.Time
+	String() string
+}
+
+type field struct {
+	min, max int
+	values   map[int]bool
+	// any is true for '*', cron matches day of month or day of week if
+	// both are restricted
+	any bool
+}
+
+type cron struct {
+	spec                          string
+	minute, hour, dom, month, dow field
+}
+
+// Parse parses a cron expression with the fields minute, hour, day of month,
+// month and day of week, each field supports '*', lists, ranges and steps
+// (i.e. '*/15 8-18 * * 1-5'), the macros '@hourly', '@daily', '@weekly',
+// '@monthly' and '@yearly' are supported as well
+func Parse(spec string) (Schedule, error) {
+	expr := strings.TrimSpace(spec)
+	if macro, found := macros[expr]; found {
+		expr = macro
+	}
+	parts := strings.Fields(expr)
+	if len(parts) != 5 {
+		return nil, fmt.Errorf("Expected 5 fields in cron expression '%s' but found %d", spec, len(parts))
+	}
+	c := &cron{spec: spec}
+	var err error
+	bounds := []struct {
+		field    *field
+		name     string
+		min, max int
+	}{
+		{&c.minute, "minute", 0, 59},
+		{&c.hour, "hour", 0, 23},
+		{&c.dom, "day of month", 1, 31},
+		{&c.month, "month", 1, 12},
+		{&c.dow, "day of week", 0, 7},
+	}
+	for i, b := range bounds {
+		if *b.field, err = parseField(parts[i], b.min, b.max); err != nil {
+			return nil, fmt.Errorf("Invalid %s in cron expression '%s': %s", b.name, spec, err.Error())
+		}
+	}
+	// sunday is 0 or 7
+	if c.dow.values[7] {
+		c.dow.values[0] = true
+	}
+	return c, nil
+}
+
+func parseField(s string, min, max int) (field, error) {
+	f := field{min: min, max: max, values: map[int]bool{}, any: s == "*"}
+	for _, part := range strings.Split(s, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step < 1 {
+				return f, fmt.Errorf("Invalid step '%s'", part[i+1:])
+			}
+			part = part[:i]
+		}
+		lo, hi := min, max
+		if part != "*" {
+			var err error
+			bounds := strings.SplitN(part, "-", 2)
+			if lo, err = strconv.Atoi(bounds[0]); err != nil {
+				return f, fmt.Errorf("Invalid value '%s'", bounds[0])
+			}
+			hi = lo
+			if len(bounds) == 2 {
+				if hi, err = strconv.Atoi(bounds[1]); err != nil {
+					return f, fmt.Errorf("Invalid value '%s'", bounds[1])
+				}
+			} else if step > 1 {
+				// 'n/step' runs from n to the max
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return f, fmt.Errorf("Range '%s' out of bounds %d-%d", part, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			f.values[v] = true
+		}
+	}
+	return f, nil
+}
+
+func (c *cron) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	end := t.Add(maxLookahead)
+	for t.Before(end) {
+		if !c.month.values[int(t.Month())] {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.day(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.hour.values[t.Hour()] {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.minute.values[t.Minute()] {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (c *cron) day(t time.Time) bool {
+	dom, dow := c.dom.values[t.Day()], c.dow.values[int(t.Weekday())]
+	switch {
+	case c.dom.any && c.dow.any:
+		return true
+	case c.dom.any:
+		return dow
+	case c.dow.any:
+		return dom
+	default:
+		return dom || dow
+	}
+}
+
+func (c *cron) String() string {
+	return c.spec
+}
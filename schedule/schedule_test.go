@@ -0,0 +1,44 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	// 2021-03-05 is a friday
+	now := time.Date(2021, 3, 5, 10, 7, 30, 0, time.UTC)
+	tests := []struct {
+		spec     string
+		expected time.Time
+	}{
+		{"*/15 * * * *", time.Date(2021, 3, 5, 10, 15, 0, 0, time.UTC)},
+		{"@daily", time.Date(2021, 3, 6, 0, 0, 0, 0, time.UTC)},
+		{"0 8-18/2 * * 1-5", time.Date(2021, 3, 5, 12, 0, 0, 0, time.UTC)},
+		{"30 2 * * 0", time.Date(2021, 3, 7, 2, 30, 0, 0, time.UTC)},
+		{"30 2 * * 7", time.Date(2021, 3, 7, 2, 30, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)},
+		// day of month or day of week if both are restricted
+		{"0 0 10 * 1", time.Date(2021, 3, 8, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 31 2 *", time.Time{}},
+	}
+	for _, test := range tests {
+		s, err := Parse(test.spec)
+		if err != nil {
+			t.Errorf("Failed to parse '%s': %s", test.spec, err.Error())
+			continue
+		}
+		if next := s.Next(now); !next.Equal(test.expected) {
+			t.Errorf("Expected next run of '%s' at %s but found %s", test.spec, test.expected, next)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, spec := range []string{"* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *", "a * * * *", "5-1 * * * *"} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("Expected error for cron expression '%s'", spec)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package scope
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+const (
+	ModeAll    = "all"
+	ModeHost   = "host"
+	ModeDomain = "domain"
+	ModePrefix = "prefix"
+	ModeHosts  = "hosts"
+)
+
+// Scope decides which links are followed
+type Scope interface {
+	InScope(link string) bool
+	Mode() string
+}
+
+type scope struct {
+	mode    string
+	allowed []string
+}
+
+// New creates the scope of mode for the seed urls, mode 'hosts' allows the
+// given hosts instead of the seed hosts. Hosts prefixed with '*.' allow the
+// domain itself and all of its subdomains, mode 'host' ignores the port
+func New(mode string, seeds []string, hosts []string) (Scope, error) {
+	s := &scope{mode: mode, allowed: []string{}}
+	switch mode {
+	case ModeAll:
+	case ModeHosts:
+		if len(hosts) == 0 {
+			return nil, fmt.Errorf("Scope '%s' requires at least one host", mode)
+		}
+		for _, host := range hosts {
+			s.allowed = append(s.allowed, strings.ToLower(host))
+		}
+	case ModeHost, ModeDomain, ModePrefix:
+		for _, seed := range seeds {
+			u, err := url.Parse(seed)
+			if err != nil {
+				return nil, err
+			}
+			key, err := s.key(u)
+			if err != nil {
+				return nil, fmt.Errorf("Failed to get scope of seed '%s': %s", seed, err.Error())
+			}
+			s.allowed = append(s.allowed, key)
+		}
+	default:
+		return nil, fmt.Errorf("Unknown scope '%s', expected '%s', '%s', '%s', '%s' or '%s'", mode, ModeAll, ModeHost, ModeDomain, ModePrefix, ModeHosts)
+	}
+	return s, nil
+}
+
+func (s *scope) Mode() string {
+	return s.mode
+}
+
+func (s *scope) InScope(link string) bool {
+	if s.mode == ModeAll {
+		return true
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+	if s.mode == ModeHosts {
+		return s.allowedHost(strings.ToLower(u.Hostname()))
+	}
+	key, err := s.key(u)
+	if err != nil {
+		return false
+	}
+	for _, allowed := range s.allowed {
+		if s.mode == ModePrefix && strings.HasPrefix(key, allowed) {
+			return true
+		}
+		if key == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// key returns the part of the url that must match a seed
+func (s *scope) key(u *url.URL) (string, error) {
+	host := strings.ToLower(u.Hostname())
+	switch s.mode {
+	case ModeDomain:
+		domain, err := publicsuffix.EffectiveTLDPlusOne(host)
+		if err != nil {
+			// hosts like 'localhost' or ip addresses have no registrable domain
+			return host, nil
+		}
+		return domain, nil
+	case ModePrefix:
+		// the prefix is the directory of the seed path
+		path := u.EscapedPath()
+		path = path[:strings.LastIndex(path, "/")+1]
+		return strings.ToLower(u.Scheme) + "://" + strings.ToLower(u.Host) + path, nil
+	default:
+		return host, nil
+	}
+}
+
+func (s *scope) allowedHost(host string) bool {
+	for _, allowed := range s.allowed {
+		if host == allowed {
+			return true
+		}
+		if strings.HasPrefix(allowed, "*.") && (host == allowed[2:] || strings.HasSuffix(host, allowed[1:])) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package scope
+
+import "testing"
+
+func TestScopes(t *testing.T) {
+	seeds := []string{"https://www.example.co.uk/docs/index.html"}
+	tests := []struct {
+		mode     string
+		hosts    []string
+		link     string
+		expected bool
+	}{
+		{ModeAll, nil, "https://cdn.other.com/x", true},
+		{ModeHost, nil, "https://WWW.example.co.uk/other", true},
+		{ModeHost, nil, "https://www.example.co.uk:8443/other", true},
+		{ModeHost, nil, "https://shop.example.co.uk/", false},
+		{ModeDomain, nil, "https://shop.example.co.uk/", true},
+		{ModeDomain, nil, "https://example.com/", false},
+		{ModeDomain, nil, "https://other.co.uk/", false},
+		{ModePrefix, nil, "https://www.example.co.uk/docs/a/b.html", true},
+		{ModePrefix, nil, "https://www.example.co.uk/blog/", false},
+		{ModePrefix, nil, "http://www.example.co.uk/docs/", false},
+		{ModeHosts, []string{"a.com", "*.b.com"}, "https://a.com/x", true},
+		{ModeHosts, []string{"a.com", "*.b.com"}, "https://sub.a.com/x", false},
+		{ModeHosts, []string{"a.com", "*.b.com"}, "https://x.y.b.com/", true},
+		{ModeHosts, []string{"a.com", "*.b.com"}, "https://b.com/", true},
+		{ModeHosts, []string{"a.com", "*.b.com"}, "https://ab.com/", false},
+	}
+	for _, test := range tests {
+		s, err := New(test.mode, seeds, test.hosts)
+		if err != nil {
+			t.Fatalf("Failed to create scope '%s': %s", test.mode, err.Error())
+		}
+		if s.InScope(test.link) != test.expected {
+			t.Errorf("Expected InScope of '%s' with scope '%s' to be %v", test.link, test.mode, test.expected)
+		}
+	}
+}
+
+func TestUnknownScope(t *testing.T) {
+	if _, err := New("site", nil, nil); err == nil {
+		t.Errorf("Expected error for unknown scope")
+	}
+	if _, err := New(ModeHosts, nil, nil); err == nil {
+		t.Errorf("Expected error for hosts scope without hosts")
+	}
+}
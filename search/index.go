@@ -0,0 +1,254 @@
+package search
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+const (
+	// bm25 parameters
+	k1 = 1.2
+	b  = 0.75
+	// words of a snippet before and after the first match
+	snippetBefore = 10
+	snippetAfter  = 20
+)
+
+// Result is a page matching a query
+type Result struct {
+	URL     string  `json:"url"`
+	Score   float64 `json:"score"`
+	Snippet string  `json:"snippet"`
+}
+
+// Index is an inverted index of the text of the crawled pages, pages
+// crawled again replace the previous version
+type Index interface {
+	Add(url, text string)
+	Len() int
+	// Search returns the pages matching any term of the query ranked by bm25,
+	// limit 0 returns all pages
+	Search(query string, limit int) []Result
+	Save() error
+}
+
+type posting struct {
+	Doc  int `json:"doc"`
+	Freq int `json:"freq"`
+}
+
+type document struct {
+	URL    string `json:"url"`
+	Text   string `json:"text"`
+	Length int    `json:"length"`
+}
+
+// file is the format of the index on disk
+type file struct {
+	Docs     []document           `json:"docs"`
+	Postings map[string][]posting `json:"postings"`
+}
+
+type index struct {
+	filename string
+	docs     []document
+	ids      map[string]int
+	postings map[string][]posting
+	// dirty is true if documents were added since postings were built
+	dirty bool
+	mux   sync.Mutex
+}
+
+// Open loads the index from the file, the index is empty if the file does
+// not exist
+func Open(filename string) (Index, error) {
+	idx := &index{
+		filename: filename,
+		docs:     []document{},
+		ids:      map[string]int{},
+		postings: map[string][]posting{},
+		mux:      sync.Mutex{},
+	}
+	dat, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return idx, nil
+	} else if err != nil {
+		return nil, err
+	}
+	f := file{}
+	if err = json.Unmarshal(dat, &f); err != nil {
+		return nil, err
+	}
+	idx.docs = f.Docs
+	if f.Postings != nil {
+		idx.postings = f.Postings
+	}
+	for i, doc := range idx.docs {
+		idx.ids[doc.URL] = i
+	}
+	return idx, nil
+}
+
+func (idx *index) Add(url, text string) {
+	idx.mux.Lock()
+	defer idx.mux.Unlock()
+	doc := document{URL: url, Text: text, Length: len(Tokenize(text))}
+	if i, found := idx.ids[url]; found {
+		idx.docs[i] = doc
+	} else {
+		idx.ids[url] = len(idx.docs)
+		idx.docs = append(idx.docs, doc)
+	}
+	idx.dirty = true
+}
+
+func (idx *index) Len() int {
+	idx.mux.Lock()
+	defer idx.mux.Unlock()
+	return len(idx.docs)
+}
+
+// build rebuilds the postings of all documents, replaced documents would
+// leave stale postings otherwise
+func (idx *index) build() {
+	if !idx.dirty {
+		return
+	}
+	idx.postings = map[string][]posting{}
+	for i, doc := range idx.docs {
+		freqs := map[string]int{}
+		for _, term := range Tokenize(doc.Text) {
+			freqs[term]++
+		}
+		for term, freq := range freqs {
+			idx.postings[term] = append(idx.postings[term], posting{Doc: i, Freq: freq})
+		}
+	}
+	idx.dirty = false
+}
+
+func (idx *index) Search(query string, limit int) []Result {
+	idx.mux.Lock()
+	defer idx.mux.Unlock()
+	idx.build()
+	ret := []Result{}
+	if len(idx.docs) == 0 {
+		return ret
+	}
+	total := 0
+	for _, doc := range idx.docs {
+		total += doc.Length
+	}
+	avgLength := float64(total) / float64(len(idx.docs))
+	terms := unique(Tokenize(query))
+	scores := map[int]float64{}
+	for _, term := range terms {
+		postings := idx.postings[term]
+		n := float64(len(postings))
+		idf := math.Log(1 + (float64(len(idx.docs))-n+0.5)/(n+0.5))
+		for _, p := range postings {
+			tf := float64(p.Freq)
+			norm := 1 - b + b*float64(idx.docs[p.Doc].Length)/avgLength
+			scores[p.Doc] += idf * tf * (k1 + 1) / (tf + k1*norm)
+		}
+	}
+	for i, score := range scores {
+		ret = append(ret, Result{URL: idx.docs[i].URL, Score: score})
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Score != ret[j].Score {
+			return ret[i].Score > ret[j].Score
+		}
+		return ret[i].URL < ret[j].URL
+	})
+	if limit > 0 && len(ret) > limit {
+		ret = ret[:limit]
+	}
+	for i := range ret {
+		ret[i].Snippet = Snippet(idx.docs[idx.ids[ret[i].URL]].Text, terms)
+	}
+	return ret
+}
+
+// Save writes the index to a temporary file that replaces the index file,
+// so that an interrupted write keeps the previous index
+func (idx *index) Save() error {
+	idx.mux.Lock()
+	defer idx.mux.Unlock()
+	idx.build()
+	dat, err := json.Marshal(file{Docs: idx.docs, Postings: idx.postings})
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(idx.filename), filepath.Base(idx.filename)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(dat); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), idx.filename)
+}
+
+// Tokenize splits the text into lowercase words of letters and numbers
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// Snippet returns the words around the first word matching a term
+func Snippet(text string, terms []string) string {
+	words := strings.Fields(text)
+	match := 0
+	for i, word := range words {
+		if containsAny(Tokenize(word), terms) {
+			match = i
+			break
+		}
+	}
+	start, end := match-snippetBefore, match+snippetAfter
+	prefix, suffix := "...", "..."
+	if start <= 0 {
+		start, prefix = 0, ""
+	}
+	if end >= len(words) {
+		end, suffix = len(words), ""
+	}
+	return prefix + strings.Join(words[start:end], " ") + suffix
+}
+
+func containsAny(tokens, terms []string) bool {
+	for _, token := range tokens {
+		for _, term := range terms {
+			if token == term {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func unique(terms []string) []string {
+	ret := []string{}
+	found := map[string]bool{}
+	for _, term := range terms {
+		if !found[term] {
+			found[term] = true
+			ret = append(ret, term)
+		}
+	}
+	return ret
+}
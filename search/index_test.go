@@ -0,0 +1,60 @@
+package search
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "index")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %s", err.Error())
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "wiki.idx")
+
+	idx, err := Open(filename)
+	if err != nil {
+		t.Fatalf("Failed to open new index: %s", err.Error())
+	}
+	idx.Add("http://wiki/deploy", "How to deploy the service. Deploy with the pipeline, deploy often.")
+	idx.Add("http://wiki/oncall", "The oncall rotation. Ask oncall before you deploy on fridays.")
+	idx.Add("http://wiki/lunch", "Lunch menu of the week")
+	if err = idx.Save(); err != nil {
+		t.Fatalf("Failed to save index: %s", err.Error())
+	}
+
+	idx, err = Open(filename)
+	if err != nil {
+		t.Fatalf("Failed to load index: %s", err.Error())
+	}
+	results := idx.Search("Deploy", 0)
+	if len(results) != 2 || results[0].URL != "http://wiki/deploy" || results[1].URL != "http://wiki/oncall" {
+		t.Fatalf("Unexpected results %+v", results)
+	}
+	if results[0].Snippet != "How to deploy the service. Deploy with the pipeline, deploy often." {
+		t.Errorf("Unexpected snippet '%s'", results[0].Snippet)
+	}
+
+	// a crawled page replaces the previous version
+	idx.Add("http://wiki/lunch", "Lunch menu, no deploy talk")
+	if results = idx.Search("menu", 0); len(results) != 1 || results[0].URL != "http://wiki/lunch" {
+		t.Errorf("Unexpected results %+v", results)
+	}
+	if results = idx.Search("week", 0); len(results) != 0 {
+		t.Errorf("Expected replaced text not to match but found %+v", results)
+	}
+	if results = idx.Search("deploy", 1); len(results) != 1 {
+		t.Errorf("Expected limit of 1 result but found %d", len(results))
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	text := "a b c d e f g h i j k l m n o p q r s t u v w x y z 1 2 3 4 5 6 7 8 9"
+	expected := "...b c d e f g h i j k l m n o p q r s t u v w x y z 1 2 3 4 5..."
+	if snippet := Snippet(text, []string{"l"}); snippet != expected {
+		t.Errorf("Expected snippet '%s' but found '%s'", expected, snippet)
+	}
+}
@@ -0,0 +1,83 @@
+package session
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/input"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/markoczy/crawler/cli"
+	"github.com/markoczy/crawler/logger"
+)
+
+// Login navigates the page to the login url, fills the login fields, submits
+// the form and waits until the success condition is met
+func Login(page *rod.Page, cfg cli.CrawlerConfig, log logger.Logger) error {
+	var err error
+	var el *rod.Element
+	page = page.Timeout(cfg.Timeout())
+
+	log.Info("Navigating to login url '%s'", cfg.LoginUrl())
+	if err = page.Navigate(cfg.LoginUrl()); err != nil {
+		return err
+	}
+	if err = page.WaitLoad(); err != nil {
+		return err
+	}
+
+	for _, field := range cfg.LoginFields() {
+		log.Debug("Filling login field '%s'", field.Selector)
+		if el, err = page.Element(field.Selector); err != nil {
+			return fmt.Errorf("Login field '%s' not found: %s", field.Selector, err.Error())
+		}
+		if err = el.SelectAllText(); err != nil {
+			return err
+		}
+		if err = el.Input(field.Value); err != nil {
+			return err
+		}
+	}
+
+	if cfg.LoginSubmit() != "" {
+		log.Debug("Clicking submit '%s'", cfg.LoginSubmit())
+		if el, err = page.Element(cfg.LoginSubmit()); err != nil {
+			return fmt.Errorf("Login submit '%s' not found: %s", cfg.LoginSubmit(), err.Error())
+		}
+		if err = el.Click(proto.InputMouseButtonLeft); err != nil {
+			return err
+		}
+	} else if el != nil {
+		log.Debug("Submitting login form by pressing enter")
+		if err = el.Press(input.Enter); err != nil {
+			return err
+		}
+	}
+
+	if cfg.LoginWait() != "" {
+		log.Debug("Waiting for element '%s'", cfg.LoginWait())
+		if _, err = page.Element(cfg.LoginWait()); err != nil {
+			return fmt.Errorf("Login success element '%s' not found: %s", cfg.LoginWait(), err.Error())
+		}
+	}
+	return waitForUrl(page, cfg, log)
+}
+
+func waitForUrl(page *rod.Page, cfg cli.CrawlerConfig, log logger.Logger) error {
+	var err error
+	var info *proto.TargetTargetInfo
+	deadline := time.Now().Add(cfg.Timeout())
+	for {
+		if info, err = page.Info(); err != nil {
+			return err
+		}
+		if cfg.LoginSuccess().MatchString(info.URL) {
+			log.Info("Login succeeded at url '%s'", info.URL)
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("Login did not succeed: url '%s' not matching login-success pattern", info.URL)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
@@ -0,0 +1,48 @@
+package session
+
+import (
+	"flag"
+	"reflect"
+	"testing"
+
+	"github.com/markoczy/crawler/cli"
+)
+
+func TestLoginFields(t *testing.T) {
+	t.Setenv("CRAWLER_TEST_USER", "alice")
+	t.Setenv("CRAWLER_TEST_PASSWORD", "a=b c")
+	tests := []struct {
+		fields   []string
+		expected []cli.LoginField
+		valid    bool
+	}{
+		{[]string{}, []cli.LoginField{}, true},
+		{[]string{"#user=CRAWLER_TEST_USER"}, []cli.LoginField{{Selector: "#user", Value: "alice"}}, true},
+		{[]string{" #user = CRAWLER_TEST_USER ", "#pass=CRAWLER_TEST_PASSWORD"}, []cli.LoginField{{Selector: "#user", Value: "alice"}, {Selector: "#pass", Value: "a=b c"}}, true},
+		// selectors may contain '='
+		{[]string{"input[name=user]=CRAWLER_TEST_USER"}, []cli.LoginField{{Selector: "input[name=user]", Value: "alice"}}, true},
+		{[]string{"#user"}, nil, false},
+		{[]string{"=CRAWLER_TEST_USER"}, nil, false},
+		{[]string{"#user=CRAWLER_TEST_UNDEFINED"}, nil, false},
+	}
+	for _, test := range tests {
+		args := []string{"-url", "http://localhost/"}
+		for _, field := range test.fields {
+			args = append(args, "-login-field", field)
+		}
+		cfg, err := cli.ParseJobArgs(flag.NewFlagSet("login", flag.ContinueOnError), args)
+		if !test.valid {
+			if err == nil {
+				t.Errorf("Expected an error for login fields %v", test.fields)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Failed to parse login fields %v: %s", test.fields, err.Error())
+			continue
+		}
+		if !reflect.DeepEqual(cfg.LoginFields(), test.expected) {
+			t.Errorf("Expected login fields %v but found %v", test.expected, cfg.LoginFields())
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package session
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	httpOnlyPrefix = "#HttpOnly_"
+	netscapeHeader = "# Netscape HTTP Cookie File\n"
+)
+
+// Import reads cookies from a file in the netscape cookies.txt format
+func (s *session) Import(filename string) error {
+	var err error
+	var file *os.File
+	var cookies []*cookie
+	if file, err = os.Open(filename); err != nil {
+		return err
+	}
+	defer file.Close()
+	if cookies, err = readNetscape(file); err != nil {
+		return err
+	}
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	for _, c := range cookies {
+		s.cookies[c.key()] = c
+	}
+	return nil
+}
+
+// Export writes all cookies of the session to a file in the netscape
+// cookies.txt format
+func (s *session) Export(filename string) error {
+	var err error
+	var file *os.File
+	if file, err = os.Create(filename); err != nil {
+		return err
+	}
+	defer file.Close()
+	s.mux.Lock()
+	cookies := []*cookie{}
+	for _, c := range s.cookies {
+		cookies = append(cookies, c)
+	}
+	s.mux.Unlock()
+	sort.Slice(cookies, func(i, j int) bool {
+		return cookies[i].key() < cookies[j].key()
+	})
+	return writeNetscape(file, cookies)
+}
+
+func readNetscape(r io.Reader) ([]*cookie, error) {
+	ret := []*cookie{}
+	scanner := bufio.NewScanner(r)
+	lineNr := 0
+	for scanner.Scan() {
+		lineNr++
+		line := strings.TrimSpace(scanner.Text())
+		httpOnly := false
+		if strings.HasPrefix(line, httpOnlyPrefix) {
+			line = line[len(httpOnlyPrefix):]
+			httpOnly = true
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		split := strings.Split(line, "\t")
+		if len(split) < 7 {
+			return nil, fmt.Errorf("Could not parse cookie at line %d: expected 7 tab separated fields but found %d", lineNr, len(split))
+		}
+		expires, err := strconv.ParseInt(split[4], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Could not parse cookie expiry at line %d: %s", lineNr, err.Error())
+		}
+		c := &cookie{
+			Name:     split[5],
+			Value:    split[6],
+			Domain:   strings.ToLower(strings.TrimPrefix(split[0], ".")),
+			Path:     split[2],
+			HostOnly: strings.ToUpper(split[1]) != "TRUE",
+			Secure:   strings.ToUpper(split[3]) == "TRUE",
+			HTTPOnly: httpOnly,
+		}
+		if expires > 0 {
+			c.Expires = time.Unix(expires, 0)
+		}
+		ret = append(ret, c)
+	}
+	return ret, scanner.Err()
+}
+
+func writeNetscape(w io.Writer, cookies []*cookie) error {
+	if _, err := io.WriteString(w, netscapeHeader); err != nil {
+		return err
+	}
+	for _, c := range cookies {
+		domain := c.Domain
+		if !c.HostOnly {
+			domain = "." + domain
+		}
+		if c.HTTPOnly {
+			domain = httpOnlyPrefix + domain
+		}
+		var expires int64
+		if !c.Expires.IsZero() {
+			expires = c.Expires.Unix()
+		}
+		line := strings.Join([]string{
+			domain,
+			netscapeBool(!c.HostOnly),
+			c.Path,
+			netscapeBool(c.Secure),
+			strconv.FormatInt(expires, 10),
+			c.Name,
+			c.Value,
+		}, "\t")
+		if _, err := io.WriteString(w, line+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func netscapeBool(b bool) string {
+	if b {
+		return "TRUE"
+	}
+	return "FALSE"
+}
@@ -0,0 +1,57 @@
+package session
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+const cookiesTxt = `# Netscape HTTP Cookie File
+.example.com	TRUE	/	FALSE	0	shared	a
+#HttpOnly_www.example.com	FALSE	/app	TRUE	4102444800	secret	b
+`
+
+func TestNetscapeRoundTrip(t *testing.T) {
+	cookies, err := readNetscape(strings.NewReader(cookiesTxt))
+	if err != nil {
+		t.Fatalf("Failed to read cookies: %s", err.Error())
+	}
+	if len(cookies) != 2 {
+		t.Fatalf("Expected 2 cookies but found %d", len(cookies))
+	}
+	if cookies[0].HostOnly || !cookies[1].HostOnly || !cookies[1].HTTPOnly || !cookies[1].Secure {
+		t.Errorf("Cookie flags not parsed correctly: %+v %+v", cookies[0], cookies[1])
+	}
+
+	buf := &bytes.Buffer{}
+	if err = writeNetscape(buf, cookies); err != nil {
+		t.Fatalf("Failed to write cookies: %s", err.Error())
+	}
+	if buf.String() != cookiesTxt {
+		t.Errorf("Unexpected output:\n%s", buf.String())
+	}
+}
+
+func TestSessionCookies(t *testing.T) {
+	cookies, _ := readNetscape(strings.NewReader(cookiesTxt))
+	s := New().(*session)
+	for _, c := range cookies {
+		s.cookies[c.key()] = c
+	}
+
+	tests := map[string]int{
+		"http://example.com/":                 1,
+		"http://sub.example.com/app":          1,
+		"http://www.example.com/app/page":     1,
+		"https://www.example.com/app/page":    2,
+		"https://www.example.com/application": 1,
+		"https://other.com/":                  0,
+	}
+	for raw, expected := range tests {
+		u, _ := url.Parse(raw)
+		if found := len(s.Cookies(u)); found != expected {
+			t.Errorf("Expected %d cookies for '%s' but found %d", expected, raw, found)
+		}
+	}
+}
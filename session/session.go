@@ -0,0 +1,250 @@
+package session
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"golang.org/x/net/publicsuffix"
+)
+
+// Session is the cookie jar shared by the browser, the hijack client and the
+// downloader, it can be synchronized with a browser instance and imported or
+// exported in the netscape cookies.txt format
+type Session interface {
+	http.CookieJar
+	Client() *http.Client
+	SetTransport(rt http.RoundTripper)
+	Capture(b *rod.Browser) error
+	Apply(b *rod.Browser) error
+	Import(filename string) error
+	Export(filename string) error
+	Len() int
+}
+
+type cookie struct {
+	Name     string
+	Value    string
+	Domain   string
+	Path     string
+	HostOnly bool
+	Secure   bool
+	HTTPOnly bool
+	// zero for session cookies
+	Expires time.Time
+}
+
+type session struct {
+	cookies map[string]*cookie
+	client  *http.Client
+	mux     sync.Mutex
+}
+
+func (s *session) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	now := time.Now()
+	for _, c := range cookies {
+		cur := &cookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Domain:   strings.ToLower(u.Hostname()),
+			Path:     c.Path,
+			HostOnly: true,
+			Secure:   c.Secure,
+			HTTPOnly: c.HttpOnly,
+		}
+		if c.Domain != "" {
+			domain := strings.ToLower(strings.TrimPrefix(c.Domain, "."))
+			if !allowDomain(cur.Domain, domain) {
+				continue
+			}
+			// a domain cookie for the host itself is host-only if the host is
+			// a public suffix (like net/http/cookiejar)
+			cur.Domain, cur.HostOnly = domain, domain == cur.Domain && isPublicSuffix(domain)
+		}
+		if cur.Path == "" || !strings.HasPrefix(cur.Path, "/") {
+			cur.Path = defaultPath(u.Path)
+		}
+		if c.MaxAge > 0 {
+			cur.Expires = now.Add(time.Duration(c.MaxAge) * time.Second)
+		} else if c.MaxAge == 0 && !c.Expires.IsZero() {
+			cur.Expires = c.Expires
+		}
+		if c.MaxAge < 0 || (!cur.Expires.IsZero() && cur.Expires.Before(now)) {
+			delete(s.cookies, cur.key())
+			continue
+		}
+		s.cookies[cur.key()] = cur
+	}
+}
+
+func (s *session) Cookies(u *url.URL) []*http.Cookie {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	now := time.Now()
+	host := strings.ToLower(u.Hostname())
+	reqPath := u.Path
+	if reqPath == "" {
+		reqPath = "/"
+	}
+	matches := []*cookie{}
+	for key, c := range s.cookies {
+		if !c.Expires.IsZero() && c.Expires.Before(now) {
+			delete(s.cookies, key)
+			continue
+		}
+		if c.Secure && u.Scheme != "https" {
+			continue
+		}
+		if !c.matchDomain(host) || !c.matchPath(reqPath) {
+			continue
+		}
+		matches = append(matches, c)
+	}
+	// cookies with longer paths are listed first
+	sort.Slice(matches, func(i, j int) bool {
+		return len(matches[i].Path) > len(matches[j].Path)
+	})
+	ret := []*http.Cookie{}
+	for _, c := range matches {
+		ret = append(ret, &http.Cookie{Name: c.Name, Value: c.Value})
+	}
+	return ret
+}
+
+func (s *session) Client() *http.Client {
+	return s.client
+}
+
+// SetTransport sets the transport of the session client, i.e. a proxy pool
+func (s *session) SetTransport(rt http.RoundTripper) {
+	s.client.Transport = rt
+}
+
+// Capture merges all cookies of the browser into the session
+func (s *session) Capture(b *rod.Browser) error {
+	var err error
+	var cookies []*proto.NetworkCookie
+	if cookies, err = b.GetCookies(); err != nil {
+		return err
+	}
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	for _, c := range cookies {
+		cur := &cookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Domain:   strings.TrimPrefix(c.Domain, "."),
+			Path:     c.Path,
+			HostOnly: !strings.HasPrefix(c.Domain, "."),
+			Secure:   c.Secure,
+			HTTPOnly: c.HTTPOnly,
+		}
+		if !c.Session {
+			cur.Expires = c.Expires.Time()
+		}
+		s.cookies[cur.key()] = cur
+	}
+	return nil
+}
+
+// Apply sets all cookies of the session in the browser
+func (s *session) Apply(b *rod.Browser) error {
+	s.mux.Lock()
+	params := []*proto.NetworkCookieParam{}
+	for _, c := range s.cookies {
+		param := &proto.NetworkCookieParam{
+			Name:     c.Name,
+			Value:    c.Value,
+			Domain:   c.Domain,
+			Path:     c.Path,
+			Secure:   c.Secure,
+			HTTPOnly: c.HTTPOnly,
+		}
+		if !c.HostOnly {
+			param.Domain = "." + c.Domain
+		}
+		if !c.Expires.IsZero() {
+			param.Expires = proto.TimeSinceEpoch(c.Expires.Unix())
+		}
+		params = append(params, param)
+	}
+	s.mux.Unlock()
+	if len(params) == 0 {
+		return nil
+	}
+	return b.SetCookies(params)
+}
+
+func (s *session) Len() int {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return len(s.cookies)
+}
+
+func New() Session {
+	s := &session{
+		cookies: map[string]*cookie{},
+		mux:     sync.Mutex{},
+	}
+	s.client = &http.Client{Jar: s}
+	return s
+}
+
+// allowDomain is true if the host may set a cookie for the domain, the host
+// must domain-match the domain and the domain must not be a public suffix
+// (i.e. 'evil.com' may not set cookies for 'bank.com' or 'com')
+func allowDomain(host, domain string) bool {
+	if host == domain {
+		return true
+	}
+	// ip addresses only set cookies for themselves
+	if net.ParseIP(host) != nil {
+		return false
+	}
+	if !strings.HasSuffix(host, "."+domain) {
+		return false
+	}
+	return !isPublicSuffix(domain)
+}
+
+func isPublicSuffix(domain string) bool {
+	suffix, _ := publicsuffix.PublicSuffix(domain)
+	return suffix == domain
+}
+
+func (c *cookie) key() string {
+	return c.Domain + ";" + c.Path + ";" + c.Name
+}
+
+func (c *cookie) matchDomain(host string) bool {
+	if host == c.Domain {
+		return true
+	}
+	return !c.HostOnly && strings.HasSuffix(host, "."+c.Domain)
+}
+
+func (c *cookie) matchPath(reqPath string) bool {
+	if reqPath == c.Path {
+		return true
+	}
+	if !strings.HasPrefix(reqPath, c.Path) {
+		return false
+	}
+	return strings.HasSuffix(c.Path, "/") || reqPath[len(c.Path)] == '/'
+}
+
+func defaultPath(reqPath string) string {
+	if reqPath == "" || !strings.HasPrefix(reqPath, "/") || strings.Count(reqPath, "/") == 1 {
+		return "/"
+	}
+	return path.Dir(reqPath)
+}
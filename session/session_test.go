@@ -0,0 +1,29 @@
+package session
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestSetCookiesDomain(t *testing.T) {
+	s := New()
+	evil, _ := url.Parse("http://evil.com/")
+	s.SetCookies(evil, []*http.Cookie{
+		{Name: "bank", Value: "1", Domain: "bank.com"},
+		{Name: "tld", Value: "2", Domain: "com"},
+		{Name: "suffix", Value: "3", Domain: ".co.uk"},
+		{Name: "own", Value: "4", Domain: ".evil.com"},
+	})
+	bank, _ := url.Parse("http://bank.com/")
+	if cookies := s.Cookies(bank); len(cookies) != 0 {
+		t.Errorf("Expected no cookies for 'bank.com' but found %v", cookies)
+	}
+	sub, _ := url.Parse("http://www.evil.com/")
+	if cookies := s.Cookies(sub); len(cookies) != 1 || cookies[0].Name != "own" {
+		t.Errorf("Expected cookie 'own' for 'www.evil.com' but found %v", cookies)
+	}
+	if s.Len() != 1 {
+		t.Errorf("Expected 1 cookie in the session but found %d", s.Len())
+	}
+}
@@ -0,0 +1,125 @@
+package simhash
+
+import (
+	"hash/fnv"
+	"math/bits"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// shingleSize is the amount of words hashed together
+const shingleSize = 3
+
+// Fingerprint computes the 64 bit simhash of the word shingles of the text,
+// similar texts have fingerprints with a small hamming distance
+func Fingerprint(text string) uint64 {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	if len(words) == 0 {
+		return 0
+	}
+	weights := [64]int{}
+	for i := 0; i == 0 || i+shingleSize <= len(words); i++ {
+		end := i + shingleSize
+		if end > len(words) {
+			end = len(words)
+		}
+		h := fnv.New64a()
+		h.Write([]byte(strings.Join(words[i:end], " ")))
+		sum := h.Sum64()
+		for bit := 0; bit < 64; bit++ {
+			if sum&(1<<uint(bit)) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+	}
+	var ret uint64
+	for bit := 0; bit < 64; bit++ {
+		if weights[bit] > 0 {
+			ret |= 1 << uint(bit)
+		}
+	}
+	return ret
+}
+
+// Distance is the hamming distance of two fingerprints
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// Index finds near-duplicate pages and groups them into clusters
+type Index interface {
+	// Add adds the page and returns the first page it is a near-duplicate of,
+	// a page that is added again (i.e. once per emulated profile) keeps the
+	// result of the first time
+	Add(url, text string) (original string, duplicate bool)
+	// Clusters returns the clusters with more than one url, the first url of
+	// a cluster is the original page
+	Clusters() [][]string
+}
+
+type entry struct {
+	url         string
+	fingerprint uint64
+}
+
+type index struct {
+	distance int
+	entries  []entry
+	clusters map[string][]string
+	// originals maps the added urls to their original, empty for originals
+	originals map[string]string
+	mux       sync.Mutex
+}
+
+// NewIndex creates an index that treats pages with a distance up to the
+// given distance as near-duplicates
+func NewIndex(distance int) Index {
+	return &index{
+		distance:  distance,
+		entries:   []entry{},
+		clusters:  map[string][]string{},
+		originals: map[string]string{},
+		mux:       sync.Mutex{},
+	}
+}
+
+func (idx *index) Add(url, text string) (string, bool) {
+	// pages without text can't be compared
+	if strings.TrimSpace(text) == "" {
+		return "", false
+	}
+	fingerprint := Fingerprint(text)
+	idx.mux.Lock()
+	defer idx.mux.Unlock()
+	if original, found := idx.originals[url]; found {
+		return original, original != ""
+	}
+	for _, e := range idx.entries {
+		if Distance(e.fingerprint, fingerprint) <= idx.distance {
+			idx.clusters[e.url] = append(idx.clusters[e.url], url)
+			idx.originals[url] = e.url
+			return e.url, true
+		}
+	}
+	idx.entries = append(idx.entries, entry{url: url, fingerprint: fingerprint})
+	idx.originals[url] = ""
+	return "", false
+}
+
+func (idx *index) Clusters() [][]string {
+	idx.mux.Lock()
+	defer idx.mux.Unlock()
+	ret := [][]string{}
+	// entries keeps the clusters in the order the originals were found
+	for _, e := range idx.entries {
+		if duplicates, found := idx.clusters[e.url]; found {
+			ret = append(ret, append([]string{e.url}, duplicates...))
+		}
+	}
+	return ret
+}
@@ -0,0 +1,53 @@
+package simhash
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const article = `The crawler visits a page and retrieves all links from the page. It recursively
+visits all links up to the specified depth and downloads files from the retrieved links.
+Regular expressions decide which links to follow or download, and capture groups of the
+url can be used in the output file names.`
+
+func TestFingerprint(t *testing.T) {
+	similar := strings.Replace(article, "specified depth", "configured depth", 1) + " Sorted by date."
+	different := `Opening hours of the city library: monday to friday from nine to six,
+saturday from ten to four. The library is closed on public holidays and during the
+summer break in august. Books can be returned at the desk in the entrance hall.`
+	if d := Distance(Fingerprint(article), Fingerprint(similar)); d > 10 {
+		t.Errorf("Expected small distance for similar texts but found %d", d)
+	}
+	if d := Distance(Fingerprint(article), Fingerprint(different)); d <= 10 {
+		t.Errorf("Expected large distance for different texts but found %d", d)
+	}
+	if Fingerprint(article) != Fingerprint(strings.ToUpper(article)) {
+		t.Errorf("Expected fingerprint to ignore case")
+	}
+}
+
+func TestIndex(t *testing.T) {
+	idx := NewIndex(5)
+	if _, dup := idx.Add("http://x/a", article); dup {
+		t.Errorf("Expected first page not to be a duplicate")
+	}
+	if _, dup := idx.Add("http://x/empty", " "); dup {
+		t.Errorf("Expected empty page not to be a duplicate")
+	}
+	original, dup := idx.Add("http://x/a?print=1", article+"\nPrint")
+	if !dup || original != "http://x/a" {
+		t.Errorf("Expected print view to be a duplicate of 'http://x/a' but found '%s' (%v)", original, dup)
+	}
+	if _, dup := idx.Add("http://x/a", article); dup {
+		t.Errorf("Expected same url not to be a duplicate")
+	}
+	// pages are added again by the crawls of other profiles
+	if original, dup = idx.Add("http://x/a?print=1", article); !dup || original != "http://x/a" {
+		t.Errorf("Expected print view to stay a duplicate of 'http://x/a' but found '%s' (%v)", original, dup)
+	}
+	expected := [][]string{{"http://x/a", "http://x/a?print=1"}}
+	if !reflect.DeepEqual(idx.Clusters(), expected) {
+		t.Errorf("Expected clusters %v but found %v", expected, idx.Clusters())
+	}
+}
@@ -0,0 +1,164 @@
+package snapshot
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// lines of context around the changes of a hunk
+	diffContext = 3
+	// max size of the lcs table, larger changes are diffed as one block
+	maxTable = 4000000
+)
+
+type op struct {
+	kind byte
+	line string
+}
+
+// Unified returns the unified diff of the lines of two texts
+func Unified(a, b, fromName, toName string) string {
+	ops := diffLines(lines(a), lines(b))
+	sb := strings.Builder{}
+	sb.WriteString("--- " + fromName + "\n")
+	sb.WriteString("+++ " + toName + "\n")
+	// line numbers of a and b at each op
+	aLine, bLine := make([]int, len(ops)+1), make([]int, len(ops)+1)
+	for i, o := range ops {
+		aLine[i+1], bLine[i+1] = aLine[i], bLine[i]
+		if o.kind != '+' {
+			aLine[i+1]++
+		}
+		if o.kind != '-' {
+			bLine[i+1]++
+		}
+	}
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+		// a hunk spans all changes with less than two contexts in between
+		start := max(i-diffContext, 0)
+		end := i
+		for j := i; j < len(ops) && j <= end+2*diffContext; j++ {
+			if ops[j].kind != ' ' {
+				end = j
+			}
+		}
+		end = min(end+diffContext+1, len(ops))
+		sb.WriteString(fmt.Sprintf("@@ -%s +%s @@\n", hunkRange(aLine[start], aLine[end]-aLine[start]), hunkRange(bLine[start], bLine[end]-bLine[start])))
+		for _, o := range ops[start:end] {
+			sb.WriteString(string(o.kind) + o.line + "\n")
+		}
+		i = end
+	}
+	return sb.String()
+}
+
+func hunkRange(start, length int) string {
+	if length == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if length == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, length)
+}
+
+// lines splits the text into trimmed non-empty lines
+func lines(text string) []string {
+	ret := []string{}
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			ret = append(ret, line)
+		}
+	}
+	return ret
+}
+
+// diffLines returns the edit script from a to b by the longest common
+// subsequence of the lines between the common prefix and suffix
+func diffLines(a, b []string) []op {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	ret := []op{}
+	for _, line := range a[:prefix] {
+		ret = append(ret, op{' ', line})
+	}
+	ret = append(ret, lcsDiff(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		ret = append(ret, op{' ', line})
+	}
+	return ret
+}
+
+func lcsDiff(a, b []string) []op {
+	ret := []op{}
+	if (len(a)+1)*(len(b)+1) > maxTable {
+		for _, line := range a {
+			ret = append(ret, op{'-', line})
+		}
+		for _, line := range b {
+			ret = append(ret, op{'+', line})
+		}
+		return ret
+	}
+	// table[i][j] is the lcs length of a[i:] and b[j:]
+	table := make([][]int, len(a)+1)
+	for i := range table {
+		table[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				table[i][j] = table[i+1][j+1] + 1
+			} else {
+				table[i][j] = max(table[i+1][j], table[i][j+1])
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ret = append(ret, op{' ', a[i]})
+			i++
+			j++
+		case table[i+1][j] >= table[i][j+1]:
+			ret = append(ret, op{'-', a[i]})
+			i++
+		default:
+			ret = append(ret, op{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ret = append(ret, op{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		ret = append(ret, op{'+', b[j]})
+	}
+	return ret
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,117 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const (
+	ChangeNew     = "new"
+	ChangeRemoved = "removed"
+	ChangeChanged = "changed"
+)
+
+// Snapshot is the result of a crawl, urls are normalized
+type Snapshot struct {
+	Time  time.Time `json:"time"`
+	Links []string  `json:"links"`
+	// Pages is the text of the visited pages by url
+	Pages map[string]string `json:"pages"`
+}
+
+// Change is a difference between two snapshots, the diff of changed pages
+// is a unified diff of the text
+type Change struct {
+	URL  string
+	Kind string
+	Diff string
+}
+
+func New() *Snapshot {
+	return &Snapshot{
+		Time:  time.Now(),
+		Links: []string{},
+		Pages: map[string]string{},
+	}
+}
+
+// Load reads the snapshot from the file, nil if the file does not exist
+func Load(filename string) (*Snapshot, error) {
+	dat, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	s := New()
+	if err = json.Unmarshal(dat, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Save writes the snapshot to a temporary file that replaces the snapshot
+// file, so that an interrupted write keeps the previous snapshot
+func (s *Snapshot) Save(filename string) error {
+	dat, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(filename), filepath.Base(filename)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(dat); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filename)
+}
+
+// Compare returns the new and removed links and the pages visited in both
+// snapshots with a different text, sorted by url
+func Compare(prev, cur *Snapshot) []Change {
+	ret := []Change{}
+	prevLinks, curLinks := set(prev.Links), set(cur.Links)
+	for link := range curLinks {
+		if !prevLinks[link] {
+			ret = append(ret, Change{URL: link, Kind: ChangeNew})
+		}
+	}
+	for link := range prevLinks {
+		if !curLinks[link] {
+			ret = append(ret, Change{URL: link, Kind: ChangeRemoved})
+		}
+	}
+	for url, text := range cur.Pages {
+		prevText, found := prev.Pages[url]
+		if !found || prevText == text {
+			continue
+		}
+		diff := Unified(prevText, text, url+"\t"+prev.Time.Format(time.RFC3339), url+"\t"+cur.Time.Format(time.RFC3339))
+		ret = append(ret, Change{URL: url, Kind: ChangeChanged, Diff: diff})
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].URL != ret[j].URL {
+			return ret[i].URL < ret[j].URL
+		}
+		return ret[i].Kind < ret[j].Kind
+	})
+	return ret
+}
+
+func set(values []string) map[string]bool {
+	ret := map[string]bool{}
+	for _, v := range values {
+		ret[v] = true
+	}
+	return ret
+}
@@ -0,0 +1,62 @@
+package snapshot
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCompare(t *testing.T) {
+	prev := New()
+	prev.Time = time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	prev.Links = []string{"http://a/", "http://a/old"}
+	prev.Pages["http://a/"] = "Title\nline 1\nline 2\nline 3\nline 4\nline 5\nline 6\nline 7\nfooter"
+	cur := New()
+	cur.Time = time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC)
+	cur.Links = []string{"http://a/", "http://a/new"}
+	cur.Pages["http://a/"] = "Title\nline 1\nline 2\nline 3\nline 4\nline 5 changed\nline 6\nline 7\nfooter"
+
+	changes := Compare(prev, cur)
+	if len(changes) != 3 {
+		t.Fatalf("Expected 3 changes but found %+v", changes)
+	}
+	if changes[1].URL != "http://a/new" || changes[1].Kind != ChangeNew || changes[2].URL != "http://a/old" || changes[2].Kind != ChangeRemoved {
+		t.Errorf("Expected new and removed links but found %+v", changes[1:])
+	}
+	expected := `--- http://a/	2021-01-01T00:00:00Z
++++ http://a/	2021-01-02T00:00:00Z
+@@ -3,7 +3,7 @@
+ line 2
+ line 3
+ line 4
+-line 5
++line 5 changed
+ line 6
+ line 7
+ footer
+`
+	if changes[0].Kind != ChangeChanged || changes[0].Diff != expected {
+		t.Errorf("Expected diff\n%s\nbut found\n%s", expected, changes[0].Diff)
+	}
+}
+
+func TestUnifiedHunks(t *testing.T) {
+	// the changes are further apart than twice the context
+	a := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12"
+	b := "0\n1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11"
+	expected := `--- a
++++ b
+@@ -1,3 +1,4 @@
++0
+ 1
+ 2
+ 3
+@@ -9,4 +10,3 @@
+ 9
+ 10
+ 11
+-12
+`
+	if diff := Unified(a, b, "a", "b"); diff != expected {
+		t.Errorf("Expected diff\n%s\nbut found\n%s", expected, diff)
+	}
+}
@@ -0,0 +1,181 @@
+package structured
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+const (
+	FormatJSONLD    = "json-ld"
+	FormatMicrodata = "microdata"
+	FormatRDFa      = "rdfa"
+)
+
+// vocabPrefixes are removed from types and property names so that the same
+// schema.org data has the same shape in all formats
+var vocabPrefixes = []string{"http://schema.org/", "https://schema.org/", "schema:"}
+
+// Item is a structured data item, property values are strings, numbers,
+// booleans, nested items or lists of these. The schema.org types are written
+// as 'schemaType', 'type' is the type of the output record
+type Item struct {
+	// Format is empty for nested items
+	Format     string                 `json:"format,omitempty"`
+	Type       []string               `json:"schemaType,omitempty"`
+	ID         string                 `json:"id,omitempty"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// Raw is the structured data found by the js.GetStructuredData script, items
+// are microdata and rdfa items with a list of values per property
+type Raw struct {
+	JSONLD []string  `json:"jsonld"`
+	Items  []rawItem `json:"items"`
+}
+
+type rawItem struct {
+	Format     string                       `json:"format"`
+	Type       []string                     `json:"type"`
+	ID         string                       `json:"id"`
+	Properties map[string][]json.RawMessage `json:"properties"`
+}
+
+// Parse normalizes the raw structured data, invalid json-ld blocks are
+// skipped and counted
+func Parse(raw Raw) (items []Item, invalid int) {
+	items = []Item{}
+	for _, block := range raw.JSONLD {
+		var v interface{}
+		if err := json.Unmarshal([]byte(block), &v); err != nil {
+			invalid++
+			continue
+		}
+		items = append(items, fromJSONLD(v)...)
+	}
+	for _, raw := range raw.Items {
+		items = append(items, raw.item())
+	}
+	return items, invalid
+}
+
+// fromJSONLD returns the top level items of a json-ld block, lists and
+// @graph are flattened
+func fromJSONLD(v interface{}) []Item {
+	ret := []Item{}
+	switch v := v.(type) {
+	case []interface{}:
+		for _, elem := range v {
+			ret = append(ret, fromJSONLD(elem)...)
+		}
+	case map[string]interface{}:
+		if graph, found := v["@graph"]; found {
+			return fromJSONLD(graph)
+		}
+		item := jsonldItem(v)
+		item.Format = FormatJSONLD
+		ret = append(ret, item)
+	}
+	return ret
+}
+
+func jsonldItem(obj map[string]interface{}) Item {
+	item := Item{Properties: map[string]interface{}{}}
+	for key, value := range obj {
+		switch key {
+		case "@context":
+		case "@type":
+			item.Type = types(value)
+		case "@id":
+			item.ID, _ = value.(string)
+		default:
+			item.Properties[name(key)] = jsonldValue(value)
+		}
+	}
+	return item
+}
+
+func jsonldValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case []interface{}:
+		ret := make([]interface{}, len(v))
+		for i, elem := range v {
+			ret[i] = jsonldValue(elem)
+		}
+		return ret
+	case map[string]interface{}:
+		// value objects like {"@value": "..."} are unwrapped
+		if value, found := v["@value"]; found {
+			return value
+		}
+		return jsonldItem(v)
+	default:
+		return v
+	}
+}
+
+func (raw *rawItem) item() Item {
+	item := Item{
+		Format:     raw.Format,
+		Type:       types(raw.Type),
+		ID:         raw.ID,
+		Properties: map[string]interface{}{},
+	}
+	for key, values := range raw.Properties {
+		parsed := []interface{}{}
+		for _, value := range values {
+			if v, ok := rawValue(value); ok {
+				parsed = append(parsed, v)
+			}
+		}
+		// single values are not wrapped in a list like in json-ld
+		if len(parsed) == 1 {
+			item.Properties[name(key)] = parsed[0]
+		} else {
+			item.Properties[name(key)] = parsed
+		}
+	}
+	return item
+}
+
+// rawValue is a string or a nested item
+func rawValue(msg json.RawMessage) (interface{}, bool) {
+	var s string
+	if err := json.Unmarshal(msg, &s); err == nil {
+		return s, true
+	}
+	var nested rawItem
+	if err := json.Unmarshal(msg, &nested); err != nil {
+		return nil, false
+	}
+	item := nested.item()
+	item.Format = ""
+	return item, true
+}
+
+func types(v interface{}) []string {
+	ret := []string{}
+	switch v := v.(type) {
+	case string:
+		ret = append(ret, name(v))
+	case []string:
+		for _, t := range v {
+			ret = append(ret, name(t))
+		}
+	case []interface{}:
+		for _, t := range v {
+			if s, ok := t.(string); ok {
+				ret = append(ret, name(s))
+			}
+		}
+	}
+	return ret
+}
+
+func name(s string) string {
+	for _, prefix := range vocabPrefixes {
+		if strings.HasPrefix(s, prefix) {
+			return s[len(prefix):]
+		}
+	}
+	return s
+}
@@ -0,0 +1,71 @@
+package structured
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestParseJSONLD(t *testing.T) {
+	raw := Raw{JSONLD: []string{
+		`{"@context": "https://schema.org", "@graph": [
+			{"@type": "Product", "@id": "#p", "name": "Shoe", "offers": {"@type": "Offer", "price": 12.5}},
+			{"@type": ["schema:Organization"], "name": {"@value": "Shop"}}
+		]}`,
+		`{invalid`,
+	}}
+	items, invalid := Parse(raw)
+	if invalid != 1 {
+		t.Errorf("Expected 1 invalid block but found %d", invalid)
+	}
+	expected := []Item{
+		{Format: FormatJSONLD, Type: []string{"Product"}, ID: "#p", Properties: map[string]interface{}{
+			"name":   "Shoe",
+			"offers": Item{Type: []string{"Offer"}, Properties: map[string]interface{}{"price": 12.5}},
+		}},
+		{Format: FormatJSONLD, Type: []string{"Organization"}, Properties: map[string]interface{}{"name": "Shop"}},
+	}
+	if !reflect.DeepEqual(items, expected) {
+		t.Errorf("Expected items %+v but found %+v", expected, items)
+	}
+}
+
+func TestMarshalItem(t *testing.T) {
+	item := Item{Format: FormatJSONLD, Type: []string{"Product"}, Properties: map[string]interface{}{
+		"offers": Item{Type: []string{"Offer"}, Properties: map[string]interface{}{"price": 12.5}},
+	}}
+	dat, err := json.Marshal(item)
+	if err != nil {
+		t.Fatalf("Failed to marshal item: %s", err.Error())
+	}
+	expected := `{"format":"json-ld","schemaType":["Product"],"properties":{"offers":{"schemaType":["Offer"],"properties":{"price":12.5}}}}`
+	if string(dat) != expected {
+		t.Errorf("Expected json %s but found %s", expected, string(dat))
+	}
+}
+
+func TestParseMicrodata(t *testing.T) {
+	var raw Raw
+	err := json.Unmarshal([]byte(`{"jsonld": [], "items": [{
+		"format": "microdata", "type": ["http://schema.org/Product"], "id": "",
+		"properties": {
+			"name": ["Shoe"],
+			"image": ["http://shop/1.png", "http://shop/2.png"],
+			"offers": [{"format": "microdata", "type": ["http://schema.org/Offer"], "id": "", "properties": {"price": ["12.50"]}}]
+		}
+	}]}`), &raw)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal raw data: %s", err.Error())
+	}
+	items, _ := Parse(raw)
+	expected := []Item{
+		{Format: FormatMicrodata, Type: []string{"Product"}, Properties: map[string]interface{}{
+			"name":   "Shoe",
+			"image":  []interface{}{"http://shop/1.png", "http://shop/2.png"},
+			"offers": Item{Type: []string{"Offer"}, Properties: map[string]interface{}{"price": "12.50"}},
+		}},
+	}
+	if !reflect.DeepEqual(items, expected) {
+		t.Errorf("Expected items %+v but found %+v", expected, items)
+	}
+}
@@ -0,0 +1,164 @@
+package trap
+
+import (
+	"hash/fnv"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	ReasonPathDepth = "path-depth"
+	ReasonRepeat    = "repeating-segments"
+	ReasonPattern   = "pattern-count"
+	ReasonContent   = "duplicate-content"
+)
+
+// Limits of the trap heuristics, zero values disable a heuristic
+type Limits struct {
+	// PathDepth is the max amount of path segments
+	PathDepth int
+	// Repeats is the max amount of times a path segment may occur
+	Repeats int
+	// Patterns is the max amount of urls with the same path and query keys
+	Patterns int
+	// Content flags pages with the same text as another url of the pattern
+	Content bool
+}
+
+// Detector flags urls that are likely crawler traps, i.e. calendars,
+// session ids or endlessly nested paths
+type Detector interface {
+	// Check flags the url before it is crawled, returns the reason or an
+	// empty string
+	Check(link string) string
+	// Content flags the url after it is crawled if another url of the same
+	// pattern had the same text, pages without text are never flagged,
+	// returns the reason or an empty string
+	Content(link, text string) string
+	// Traps returns the flagged urls with their reason
+	Traps() map[string]string
+}
+
+type detector struct {
+	limits   Limits
+	patterns map[string]map[string]bool
+	texts    map[string]map[uint64]string
+	traps    map[string]string
+	mux      sync.Mutex
+}
+
+func New(limits Limits) Detector {
+	return &detector{
+		limits:   limits,
+		patterns: map[string]map[string]bool{},
+		texts:    map[string]map[uint64]string{},
+		traps:    map[string]string{},
+		mux:      sync.Mutex{},
+	}
+}
+
+func (d *detector) Check(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	if reason, found := d.traps[link]; found {
+		return reason
+	}
+	reason := d.check(link, u)
+	if reason != "" {
+		d.traps[link] = reason
+	}
+	return reason
+}
+
+func (d *detector) check(link string, u *url.URL) string {
+	segments := splitPath(u.Path)
+	if d.limits.PathDepth > 0 && len(segments) > d.limits.PathDepth {
+		return ReasonPathDepth
+	}
+	if d.limits.Repeats > 0 {
+		counts := map[string]int{}
+		for _, segment := range segments {
+			counts[segment]++
+			if counts[segment] > d.limits.Repeats {
+				return ReasonRepeat
+			}
+		}
+	}
+	if d.limits.Patterns > 0 {
+		key := pattern(u)
+		if d.patterns[key] == nil {
+			d.patterns[key] = map[string]bool{}
+		}
+		if !d.patterns[key][link] && len(d.patterns[key]) >= d.limits.Patterns {
+			return ReasonPattern
+		}
+		d.patterns[key][link] = true
+	}
+	return ""
+}
+
+func (d *detector) Content(link, text string) string {
+	if !d.limits.Content || strings.TrimSpace(text) == "" {
+		return ""
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	key := pattern(u)
+	hash := fingerprint(text)
+	if d.texts[key] == nil {
+		d.texts[key] = map[uint64]string{}
+	}
+	if other, found := d.texts[key][hash]; found && other != link {
+		d.traps[link] = ReasonContent
+		return ReasonContent
+	}
+	d.texts[key][hash] = link
+	return ""
+}
+
+func (d *detector) Traps() map[string]string {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	ret := map[string]string{}
+	for k, v := range d.traps {
+		ret[k] = v
+	}
+	return ret
+}
+
+func splitPath(path string) []string {
+	ret := []string{}
+	for _, segment := range strings.Split(path, "/") {
+		if segment != "" {
+			ret = append(ret, segment)
+		}
+	}
+	return ret
+}
+
+// pattern is the url without query values and fragment
+func pattern(u *url.URL) string {
+	keys := []string{}
+	for key := range u.Query() {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return strings.ToLower(u.Host) + u.Path + "?" + strings.Join(keys, "&")
+}
+
+// fingerprint hashes the text with collapsed whitespace
+func fingerprint(text string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(strings.Join(strings.Fields(text), " ")))
+	return h.Sum64()
+}
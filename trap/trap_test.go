@@ -0,0 +1,58 @@
+package trap
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	d := New(Limits{PathDepth: 5, Repeats: 2, Patterns: 3})
+	tests := []struct {
+		link   string
+		reason string
+	}{
+		{"http://x/a/b/c", ""},
+		{"http://x/1/2/3/4/5/6", ReasonPathDepth},
+		{"http://x/a/b/a/b", ""},
+		{"http://x/a/b/a/b/a", ReasonRepeat},
+		{"http://x/cal?month=1", ""},
+		{"http://x/cal?month=2", ""},
+		{"http://x/cal?month=1", ""},
+		{"http://x/cal?month=3", ""},
+		{"http://x/cal?month=4", ReasonPattern},
+		{"http://x/cal?month=4&day=1", ""},
+	}
+	for _, test := range tests {
+		if reason := d.Check(test.link); reason != test.reason {
+			t.Errorf("Expected reason '%s' for '%s' but found '%s'", test.reason, test.link, reason)
+		}
+	}
+	if len(d.Traps()) != 3 {
+		t.Errorf("Expected 3 traps but found %v", d.Traps())
+	}
+}
+
+func TestContent(t *testing.T) {
+	d := New(Limits{Content: true})
+	for i := 1; i <= 3; i++ {
+		d.Content(fmt.Sprintf("http://x/cal?month=%d", i), fmt.Sprintf("Events of month %d", i))
+	}
+	if reason := d.Content("http://x/cal?month=13", "Events of  month 3\n"); reason != ReasonContent {
+		t.Errorf("Expected duplicate content to be flagged but found '%s'", reason)
+	}
+	if reason := d.Content("http://x/other", "Events of month 3"); reason != "" {
+		t.Errorf("Expected same content of other pattern not to be flagged but found '%s'", reason)
+	}
+}
+
+func TestEmptyContent(t *testing.T) {
+	d := New(Limits{Content: true})
+	for i := 1; i <= 3; i++ {
+		if reason := d.Content(fmt.Sprintf("http://x/item?id=%d", i), " \n"); reason != "" {
+			t.Errorf("Expected page without text not to be flagged but found '%s'", reason)
+		}
+	}
+	if len(d.Traps()) != 0 {
+		t.Errorf("Expected no traps but found %v", d.Traps())
+	}
+}
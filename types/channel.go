@@ -7,6 +7,7 @@ import (
 type ErrorSwitchChannel interface {
 	Send(err error)
 	Receive() error
+	TryReceive() (error, bool)
 }
 
 type errorSwitchChannel struct {
@@ -30,6 +31,16 @@ func (ch *errorSwitchChannel) Receive() error {
 	return <-ch.errCh
 }
 
+// TryReceive returns the error if one was sent without blocking
+func (ch *errorSwitchChannel) TryReceive() (error, bool) {
+	select {
+	case err, ok := <-ch.errCh:
+		return err, ok
+	default:
+		return nil, false
+	}
+}
+
 func NewErrorSwitchChannel() ErrorSwitchChannel {
 	return &errorSwitchChannel{
 		done:  false,
@@ -0,0 +1,54 @@
+package types
+
+import (
+	"sync"
+)
+
+// NavigationRecorder keeps the redirects of the first navigation between
+// Start and Stop, only navigations of the main frame are recorded
+type NavigationRecorder interface {
+	Start()
+	Record(redirects []string)
+	Stop() []string
+}
+
+type navigationRecorder struct {
+	active    bool
+	recorded  bool
+	redirects []string
+	mux       sync.Mutex
+}
+
+func (rec *navigationRecorder) Start() {
+	rec.mux.Lock()
+	rec.active = true
+	rec.recorded = false
+	rec.redirects = []string{}
+	rec.mux.Unlock()
+}
+
+func (rec *navigationRecorder) Record(redirects []string) {
+	rec.mux.Lock()
+	if rec.active && !rec.recorded {
+		rec.redirects = redirects
+		rec.recorded = true
+	}
+	rec.mux.Unlock()
+}
+
+func (rec *navigationRecorder) Stop() []string {
+	rec.mux.Lock()
+	defer rec.mux.Unlock()
+	ret := rec.redirects
+	rec.active = false
+	rec.redirects = []string{}
+	return ret
+}
+
+func NewNavigationRecorder() NavigationRecorder {
+	return &navigationRecorder{
+		active:    false,
+		redirects: []string{},
+		mux:       sync.Mutex{},
+	}
+}
@@ -0,0 +1,58 @@
+package types
+
+import (
+	"sync"
+)
+
+// NetworkRequest is a single request that was observed while rendering a page
+type NetworkRequest struct {
+	URL          string
+	ResourceType string
+	Status       int
+}
+
+// RequestRecorder collects network requests between Start and Stop, requests
+// recorded while the recorder is not started are dropped
+type RequestRecorder interface {
+	Start()
+	Record(req NetworkRequest)
+	Stop() []NetworkRequest
+}
+
+type requestRecorder struct {
+	active   bool
+	requests []NetworkRequest
+	mux      sync.Mutex
+}
+
+func (rec *requestRecorder) Start() {
+	rec.mux.Lock()
+	rec.active = true
+	rec.requests = []NetworkRequest{}
+	rec.mux.Unlock()
+}
+
+func (rec *requestRecorder) Record(req NetworkRequest) {
+	rec.mux.Lock()
+	if rec.active {
+		rec.requests = append(rec.requests, req)
+	}
+	rec.mux.Unlock()
+}
+
+func (rec *requestRecorder) Stop() []NetworkRequest {
+	rec.mux.Lock()
+	defer rec.mux.Unlock()
+	ret := rec.requests
+	rec.active = false
+	rec.requests = []NetworkRequest{}
+	return ret
+}
+
+func NewRequestRecorder() RequestRecorder {
+	return &requestRecorder{
+		active:   false,
+		requests: []NetworkRequest{},
+		mux:      sync.Mutex{},
+	}
+}
@@ -0,0 +1,22 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRequestRecorder(t *testing.T) {
+	rec := NewRequestRecorder()
+	rec.Record(NetworkRequest{URL: "http://x/before"})
+	rec.Start()
+	req := NetworkRequest{URL: "http://x/api", ResourceType: "XHR", Status: 200}
+	rec.Record(req)
+	if requests := rec.Stop(); !reflect.DeepEqual(requests, []NetworkRequest{req}) {
+		t.Errorf("Expected requests %v but found %v", []NetworkRequest{req}, requests)
+	}
+	rec.Record(NetworkRequest{URL: "http://x/after"})
+	rec.Start()
+	if requests := rec.Stop(); len(requests) != 0 {
+		t.Errorf("Expected requests outside of Start and Stop to be dropped but found %v", requests)
+	}
+}
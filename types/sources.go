@@ -0,0 +1,25 @@
+package types
+
+// LinkSources tracks from which sources (dom, network, ...) a link was found
+type LinkSources map[string]*StringSet
+
+func NewLinkSources() *LinkSources {
+	sources := LinkSources(map[string]*StringSet{})
+	return &sources
+}
+
+func (sources *LinkSources) Add(link string, source ...string) {
+	set, found := (*sources)[link]
+	if !found {
+		set = NewStringSet()
+		(*sources)[link] = set
+	}
+	set.Add(source...)
+}
+
+func (sources *LinkSources) Get(link string) []string {
+	if set, found := (*sources)[link]; found {
+		return set.Values()
+	}
+	return []string{}
+}
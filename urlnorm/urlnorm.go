@@ -0,0 +1,95 @@
+package urlnorm
+
+import (
+	"net/url"
+	"strings"
+)
+
+// DefaultStripParams are common tracking parameters, a trailing '*' matches
+// all parameters with the prefix
+var DefaultStripParams = []string{"utm_*", "gclid", "fbclid", "msclkid", "mc_cid", "mc_eid", "yclid", "_ga"}
+
+// Normalizer rewrites equivalent urls to the same string
+type Normalizer interface {
+	Normalize(link string) string
+}
+
+type normalizer struct {
+	strip      []string
+	stripSlash bool
+}
+
+type none struct{}
+
+// New creates a normalizer that lowercases scheme and host, drops default
+// ports and fragments, resolves dot segments, sorts the query parameters and
+// removes the strip parameters. Trailing slashes of the path are removed if
+// stripSlash is set, so that '/a' and '/a/' are the same url
+func New(strip []string, stripSlash bool) Normalizer {
+	return &normalizer{strip: strip, stripSlash: stripSlash}
+}
+
+// None creates a normalizer that keeps urls as they are
+func None() Normalizer {
+	return &none{}
+}
+
+func (n *none) Normalize(link string) string {
+	return link
+}
+
+func (n *normalizer) Normalize(link string) string {
+	u, err := url.Parse(link)
+	if err != nil || u.Opaque != "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return link
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port != "" {
+		host += ":" + port
+	}
+	u.Host = host
+	u.Fragment = ""
+	u.RawFragment = ""
+	// resolving against an empty reference removes dot segments
+	u = u.ResolveReference(&url.URL{})
+	if n.stripSlash && len(u.Path) > 1 {
+		u.Path = strings.TrimRight(u.Path, "/")
+		u.RawPath = strings.TrimRight(u.RawPath, "/")
+	}
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	if u.RawQuery != "" {
+		query := u.Query()
+		for key := range query {
+			if n.stripped(key) {
+				query.Del(key)
+			}
+		}
+		// encode sorts by key
+		u.RawQuery = query.Encode()
+	}
+	u.ForceQuery = false
+	return u.String()
+}
+
+func (n *normalizer) stripped(key string) bool {
+	key = strings.ToLower(key)
+	for _, s := range n.strip {
+		if strings.HasSuffix(s, "*") && strings.HasPrefix(key, s[:len(s)-1]) {
+			return true
+		}
+		if key == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,42 @@
+package urlnorm
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	n := New(DefaultStripParams, true)
+	tests := map[string]string{
+		"http://x/a":                          "http://x/a",
+		"HTTP://X/a#top":                      "http://x/a",
+		"http://x/a?utm_source=mail&utm_id=1": "http://x/a",
+		"http://x:80/a":                       "http://x/a",
+		"https://x:443/a":                     "https://x/a",
+		"https://x:8443/a":                    "https://x:8443/a",
+		"http://x/b/../a/./c":                 "http://x/a/c",
+		"http://x/a?b=2&a=1&gclid=xyz":        "http://x/a?a=1&b=2",
+		"http://x":                            "http://x/",
+		"http://x/A/":                         "http://x/A",
+		"http://x/a//":                        "http://x/a",
+		"http://x/?a=1":                       "http://x/?a=1",
+		"mailto:info@x":                       "mailto:info@x",
+	}
+	for in, expected := range tests {
+		if out := n.Normalize(in); out != expected {
+			t.Errorf("Expected '%s' to be normalized to '%s' but found '%s'", in, expected, out)
+		}
+	}
+}
+
+func TestTrailingSlash(t *testing.T) {
+	if New(nil, true).Normalize("http://x/a") != New(nil, true).Normalize("http://x/a/") {
+		t.Errorf("Expected '/a' and '/a/' to be the same url")
+	}
+	if out := New(nil, false).Normalize("http://x/A/"); out != "http://x/A/" {
+		t.Errorf("Expected trailing slash to be kept but found '%s'", out)
+	}
+}
+
+func TestNone(t *testing.T) {
+	if None().Normalize("HTTP://X/a#top") != "HTTP://X/a#top" {
+		t.Errorf("Expected url to be unchanged")
+	}
+}